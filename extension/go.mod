@@ -7,7 +7,10 @@ require (
 	github.com/google/go-cmp v0.7.0
 )
 
-require github.com/google/pprof v0.0.0-20260709232956-b9395ee17fa0 // indirect
+require (
+	github.com/google/pprof v0.0.0-20260709232956-b9395ee17fa0 // indirect
+	golang.org/x/tools v0.48.0 // indirect
+)
 
 require (
 	golang.org/x/mod v0.38.0
@@ -0,0 +1,313 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var (
+	affectedFlags    = flag.NewFlagSet("affected", flag.ExitOnError)
+	affectedDir      = affectedFlags.String("C", "", "directory to run go and git commands in, and that files are relative to (default: current directory)")
+	affectedPackages = affectedFlags.String("packages", "./...", "space-separated package patterns to consider")
+	affectedDiff     = affectedFlags.String("diff", "", "also consider the files changed in this git revision range, as git diff's, such as main...HEAD")
+)
+
+// AffectedPackages lists the packages affected by changes to files.
+type AffectedPackages struct {
+	Files        []string // the changed files
+	Packages     []string // packages that depend on the changed files, directly or not
+	TestPackages []string // packages whose tests depend on the changed files
+	Unmatched    []string // changed files that no package depends on, such as documentation
+}
+
+// affectedPackage is a package as printed by go list -deps -test.
+// Packages compiled for tests have ForTest set, and test mains have an
+// import path ending in ".test".
+type affectedPackage struct {
+	ImportPath string
+	Dir        string
+	ForTest    string
+	DepOnly    bool
+	Module     *struct {
+		GoMod string
+		Main  bool
+	}
+	Imports       []string
+	GoFiles       []string
+	CgoFiles      []string
+	CFiles        []string
+	CXXFiles      []string
+	MFiles        []string
+	HFiles        []string
+	FFiles        []string
+	SFiles        []string
+	SwigFiles     []string
+	SwigCXXFiles  []string
+	SysoFiles     []string
+	EmbedFiles    []string
+	EmbedPatterns []string
+	// Test variants list their test files in GoFiles, but the
+	// embedded files of the tests only in the fields below.
+	TestEmbedFiles     []string
+	TestEmbedPatterns  []string
+	XTestEmbedFiles    []string
+	XTestEmbedPatterns []string
+}
+
+// affectedPackageFields are the fields of affectedPackage.
+const affectedPackageFields = "ImportPath,Dir,ForTest,DepOnly,Module,Imports," +
+	"GoFiles,CgoFiles,CFiles,CXXFiles,MFiles,HFiles,FFiles,SFiles,SwigFiles,SwigCXXFiles,SysoFiles," +
+	"EmbedFiles,EmbedPatterns,TestEmbedFiles,TestEmbedPatterns,XTestEmbedFiles,XTestEmbedPatterns"
+
+func (p *affectedPackage) isTestMain() bool {
+	return p.ForTest == "" && strings.HasSuffix(p.ImportPath, ".test")
+}
+
+// isTest reports whether p is compiled only for tests.
+func (p *affectedPackage) isTest() bool {
+	return p.ForTest != "" || p.isTestMain()
+}
+
+// dependsOn reports whether p depends on the file at rel, a slash-separated
+// path relative to p.Dir.
+//
+// Go files that p does not list, such as deleted files or files excluded
+// by build constraints, conservatively affect the packages in their
+// directory. Files in testdata affect the tests of the package, and
+// files matching an embed pattern affect the package even if they were
+// deleted.
+func (p *affectedPackage) dependsOn(rel string) bool {
+	if p.isTestMain() {
+		// Test mains are generated, and depend on the
+		// package's testdata.
+		return strings.HasPrefix(rel, "testdata/")
+	}
+	for _, files := range [][]string{
+		p.GoFiles, p.CgoFiles, p.CFiles, p.CXXFiles, p.MFiles, p.HFiles, p.FFiles,
+		p.SFiles, p.SwigFiles, p.SwigCXXFiles, p.SysoFiles, p.EmbedFiles,
+	} {
+		if slices.Contains(files, rel) {
+			return true
+		}
+	}
+	if !strings.Contains(rel, "/") && strings.HasSuffix(rel, ".go") {
+		return p.isTest() || !strings.HasSuffix(rel, "_test.go")
+	}
+	patterns := p.EmbedPatterns
+	if p.ForTest != "" {
+		if slices.Contains(p.TestEmbedFiles, rel) || slices.Contains(p.XTestEmbedFiles, rel) {
+			return true
+		}
+		patterns = slices.Concat(patterns, p.TestEmbedPatterns, p.XTestEmbedPatterns)
+	}
+	for _, pat := range patterns {
+		pat = strings.TrimPrefix(pat, "all:")
+		// A pattern that matches a directory embeds its tree.
+		for r := rel; r != "."; r = path.Dir(r) {
+			if ok, _ := path.Match(pat, r); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// loadAffectedPackages runs go list -deps -test for the patterns in dir.
+func loadAffectedPackages(ctx context.Context, dir string, patterns []string) ([]*affectedPackage, error) {
+	args := append([]string{"list", "-e", "-deps", "-test", "-json=" + affectedPackageFields, "--"}, patterns...)
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list: %v: %s", err, stderr.Bytes())
+	}
+	var pkgs []*affectedPackage
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var p affectedPackage
+		if err := dec.Decode(&p); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("reading go list output: %v", err)
+		}
+		pkgs = append(pkgs, &p)
+	}
+	return pkgs, nil
+}
+
+// affectedBy computes the packages among pkgs affected by changes to
+// files, given as absolute paths: the packages that depend on a file,
+// and the packages that import them, directly or not.
+func affectedBy(pkgs []*affectedPackage, files []string) *AffectedPackages {
+	byDir := map[string][]*affectedPackage{}
+	importers := map[string][]*affectedPackage{}
+	for _, p := range pkgs {
+		if p.Dir != "" {
+			byDir[p.Dir] = append(byDir[p.Dir], p)
+		}
+		for _, imp := range p.Imports {
+			importers[imp] = append(importers[imp], p)
+		}
+	}
+
+	affected := map[string]bool{}
+	var queue []*affectedPackage
+	affect := func(p *affectedPackage) {
+		if !affected[p.ImportPath] {
+			affected[p.ImportPath] = true
+			queue = append(queue, p)
+		}
+	}
+	res := &AffectedPackages{Files: files, Packages: []string{}, TestPackages: []string{}, Unmatched: []string{}}
+	for _, f := range files {
+		matched := false
+		mark := func(p *affectedPackage) {
+			matched = true
+			affect(p)
+		}
+		switch filepath.Base(f) {
+		case "go.mod", "go.sum":
+			// Changing the requirements of a module may change
+			// any of its packages.
+			for _, p := range pkgs {
+				if p.Module != nil && p.Module.GoMod != "" && filepath.Dir(p.Module.GoMod) == filepath.Dir(f) {
+					mark(p)
+				}
+			}
+		case "go.work", "go.work.sum":
+			for _, p := range pkgs {
+				if p.Module != nil && p.Module.Main {
+					mark(p)
+				}
+			}
+		}
+		for d := filepath.Dir(f); ; d = filepath.Dir(d) {
+			if ps := byDir[d]; len(ps) > 0 {
+				rel, err := filepath.Rel(d, f)
+				if err != nil {
+					break
+				}
+				for _, p := range ps {
+					if p.dependsOn(filepath.ToSlash(rel)) {
+						mark(p)
+					}
+				}
+			}
+			if filepath.Dir(d) == d {
+				break
+			}
+		}
+		if !matched {
+			res.Unmatched = append(res.Unmatched, f)
+		}
+	}
+
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		for _, q := range importers[p.ImportPath] {
+			affect(q)
+		}
+	}
+	for _, p := range pkgs {
+		if !affected[p.ImportPath] || p.DepOnly {
+			continue
+		}
+		if p.isTestMain() {
+			res.TestPackages = append(res.TestPackages, strings.TrimSuffix(p.ImportPath, ".test"))
+		} else if !p.isTest() {
+			res.Packages = append(res.Packages, p.ImportPath)
+		}
+	}
+	slices.Sort(res.Packages)
+	slices.Sort(res.TestPackages)
+	return res
+}
+
+// gitChangedFiles returns the absolute paths of the files changed in the
+// revision range, as reported by git diff run in dir.
+func gitChangedFiles(ctx context.Context, dir, revs string) ([]string, error) {
+	git := func(args ...string) (string, error) {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = dir
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("git %s: %v: %s", args[0], err, stderr.Bytes())
+		}
+		return string(out), nil
+	}
+	// git diff prints paths relative to the top of the work tree,
+	// which may be a symbolic link away from dir.
+	cdup, err := git("rev-parse", "--show-cdup")
+	if err != nil {
+		return nil, err
+	}
+	// Without renames, both the old and the new path of a renamed file
+	// are listed, as both packages are affected.
+	out, err := git("diff", "--name-only", "--no-renames", revs, "--")
+	if err != nil {
+		return nil, err
+	}
+	top, err := filepath.Abs(filepath.Join(dir, strings.TrimSpace(cdup)))
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for line := range strings.Lines(out) {
+		if line = strings.TrimSuffix(line, "\n"); line != "" {
+			files = append(files, filepath.Join(top, filepath.FromSlash(line)))
+		}
+	}
+	return files, nil
+}
+
+func runAffected(args []string) error {
+	if len(args) == 0 && *affectedDiff == "" {
+		return fmt.Errorf("usage: affected [flags] [<file>...]: no files or -diff")
+	}
+	ctx := context.Background()
+	dir, err := filepath.Abs(*affectedDir)
+	if err != nil {
+		return err
+	}
+	var files []string
+	for _, f := range args {
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(dir, f)
+		}
+		files = append(files, filepath.Clean(f))
+	}
+	if *affectedDiff != "" {
+		changed, err := gitChangedFiles(ctx, dir, *affectedDiff)
+		if err != nil {
+			return err
+		}
+		files = append(files, changed...)
+	}
+	slices.Sort(files)
+	files = slices.Compact(files)
+
+	pkgs, err := loadAffectedPackages(ctx, dir, strings.Fields(*affectedPackages))
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(os.Stdout).Encode(affectedBy(pkgs, files))
+}
@@ -0,0 +1,126 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestAffectedBy(t *testing.T) {
+	dir := writeFiles(t, t.TempDir(), map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.21\n",
+		"a/a.go": `package a
+
+import _ "embed"
+
+//go:embed static
+var s string
+`,
+		"a/static/x.txt": "x",
+		"a/a_test.go": `package a
+
+import "testing"
+
+func TestA(t *testing.T) {}
+`,
+		"a/testdata/golden": "golden",
+		"b/b.go":            "package b\n\nimport _ \"example.com/m/a\"\n",
+		"b/b_test.go": `package b_test
+
+import "testing"
+
+func TestB(t *testing.T) {}
+`,
+		"c/c.go":      "package c\n",
+		"c/c_test.go": "package c\n\nimport (\n\t\"testing\"\n\n\t_ \"example.com/m/a\"\n)\n",
+		"d/d.go":      "package d\n",
+		"README.md":   "docs",
+	})
+	pkgs, err := loadAffectedPackages(context.Background(), dir, []string{"./..."})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		files        []string
+		packages     []string
+		testPackages []string
+	}{
+		{[]string{"a/a.go"}, []string{"a", "b"}, []string{"a", "b", "c"}},
+		{[]string{"a/a_test.go"}, nil, []string{"a"}},
+		{[]string{"a/static/x.txt"}, []string{"a", "b"}, []string{"a", "b", "c"}},
+		{[]string{"a/static/deleted.txt"}, []string{"a", "b"}, []string{"a", "b", "c"}},
+		{[]string{"a/testdata/golden"}, nil, []string{"a"}},
+		{[]string{"b/b_test.go"}, nil, []string{"b"}},
+		{[]string{"d/new.go"}, []string{"d"}, nil},
+		{[]string{"go.mod"}, []string{"a", "b", "c", "d"}, []string{"a", "b", "c"}},
+		{[]string{"README.md"}, nil, nil},
+	} {
+		var files []string
+		for _, f := range test.files {
+			files = append(files, filepath.Join(dir, filepath.FromSlash(f)))
+		}
+		res := affectedBy(pkgs, files)
+		if got, want := trimModule(res.Packages), test.packages; !slices.Equal(got, want) {
+			t.Errorf("%s: packages = %q, want %q", test.files, got, want)
+		}
+		if got, want := trimModule(res.TestPackages), test.testPackages; !slices.Equal(got, want) {
+			t.Errorf("%s: test packages = %q, want %q", test.files, got, want)
+		}
+		if unmatched := len(res.Packages) == 0 && len(res.TestPackages) == 0; unmatched != (len(res.Unmatched) > 0) {
+			t.Errorf("%s: unmatched = %q", test.files, res.Unmatched)
+		}
+	}
+}
+
+// trimModule strips the module path of the test module from pkgs.
+func trimModule(pkgs []string) []string {
+	var res []string
+	for _, p := range pkgs {
+		res = append(res, p[len("example.com/m/"):])
+	}
+	return res
+}
+
+func TestGitChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir := writeFiles(t, t.TempDir(), map[string]string{
+		"go.mod":     "module example.com/m\n",
+		"sub/old.go": "package sub\n",
+	})
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=a", "GIT_AUTHOR_EMAIL=a@example.com", "GIT_COMMITTER_NAME=a", "GIT_COMMITTER_EMAIL=a@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+	git("mv", "sub/old.go", "sub/new.go")
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/m\n\ngo 1.21\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := gitChangedFiles(context.Background(), filepath.Join(dir, "sub"), "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "go.mod"), filepath.Join(dir, "sub", "new.go"), filepath.Join(dir, "sub", "old.go")}
+	slices.Sort(files)
+	if !slices.Equal(files, want) {
+		t.Errorf("changed files = %q, want %q", files, want)
+	}
+}
@@ -0,0 +1,275 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/tools/cover"
+)
+
+var (
+	coverFlags  = flag.NewFlagSet("cover", flag.ExitOnError)
+	coverDir    = coverFlags.String("C", "", "directory to run go list in to resolve import paths to directories (default: current directory)")
+	coverFormat = coverFlags.String("format", "json", "output format: json (covered and uncovered ranges), func (per-function and per-package coverage as JSON), lcov, or cobertura")
+)
+
+// CoverageReport is the coverage of a set of files, merged from
+// one or more cover profiles.
+type CoverageReport struct {
+	Mode  string
+	Files []*FileCoverage
+}
+
+// FileCoverage is the coverage of one source file.
+type FileCoverage struct {
+	FileName          string // as found in the cover profile
+	Path              string // path of the file, or FileName if it could not be resolved
+	Package           string `json:",omitempty"` // import path, if FileName is in the import path form
+	Statements        int
+	CoveredStatements int
+	Covered           []CoverRange
+	Uncovered         []CoverRange
+}
+
+// CoverRange is a block of statements. Lines and columns are 1-based,
+// and the end column is exclusive.
+type CoverRange struct {
+	StartLine, StartCol int
+	EndLine, EndCol     int
+	NumStmt             int
+	Count               int
+}
+
+// mergeCoverProfiles merges the cover profiles in the named files.
+// Counts of identical blocks are summed, or in "set" mode, or-ed.
+// Profiles in "set" mode cannot be merged with "count" or "atomic"
+// profiles; "count" and "atomic" profiles merge as "atomic".
+func mergeCoverProfiles(files []string) ([]*cover.Profile, error) {
+	type blockKey struct {
+		startLine, startCol, endLine, endCol int
+	}
+	mode := ""
+	merged := map[string]*cover.Profile{}
+	blocks := map[string]map[blockKey]int{} // file name -> block -> index in Blocks
+	for _, f := range files {
+		ps, err := cover.ParseProfiles(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", f, err)
+		}
+		for _, p := range ps {
+			switch {
+			case mode == "" || mode == p.Mode:
+				mode = p.Mode
+			case mode != "set" && p.Mode != "set":
+				mode = "atomic"
+			default:
+				return nil, fmt.Errorf("%s: cannot merge cover mode %s with %s", f, p.Mode, mode)
+			}
+			m := merged[p.FileName]
+			if m == nil {
+				m = &cover.Profile{FileName: p.FileName}
+				merged[p.FileName] = m
+				blocks[p.FileName] = map[blockKey]int{}
+			}
+			for _, b := range p.Blocks {
+				k := blockKey{b.StartLine, b.StartCol, b.EndLine, b.EndCol}
+				i, ok := blocks[p.FileName][k]
+				if !ok {
+					blocks[p.FileName][k] = len(m.Blocks)
+					m.Blocks = append(m.Blocks, b)
+					continue
+				}
+				if m.Blocks[i].NumStmt != b.NumStmt {
+					return nil, fmt.Errorf("%s: %s:%d.%d,%d.%d: inconsistent number of statements: %d and %d",
+						f, p.FileName, b.StartLine, b.StartCol, b.EndLine, b.EndCol, m.Blocks[i].NumStmt, b.NumStmt)
+				}
+				m.Blocks[i].Count += b.Count
+			}
+		}
+	}
+	res := make([]*cover.Profile, 0, len(merged))
+	for _, p := range merged {
+		p.Mode = mode
+		if mode == "set" {
+			for i := range p.Blocks {
+				p.Blocks[i].Count = min(p.Blocks[i].Count, 1)
+			}
+		}
+		slices.SortFunc(p.Blocks, func(a, b cover.ProfileBlock) int {
+			if c := cmp.Compare(a.StartLine, b.StartLine); c != 0 {
+				return c
+			}
+			return cmp.Compare(a.StartCol, b.StartCol)
+		})
+		res = append(res, p)
+	}
+	slices.SortFunc(res, func(a, b *cover.Profile) int { return cmp.Compare(a.FileName, b.FileName) })
+	return res, nil
+}
+
+// coverFilePackage returns the import path of the package of a file
+// named in a cover profile, or "" if the name is a file path.
+// Files of packages outside of GOPATH and modules are named by their
+// absolute path with a "_" prefix, and files listed on the go test
+// command line by a path relative to its working directory.
+func coverFilePackage(name string) string {
+	if filepath.IsAbs(name) || strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".") {
+		return ""
+	}
+	pkg := path.Dir(name)
+	if pkg == "." {
+		return ""
+	}
+	return pkg
+}
+
+// resolveCoverFiles returns the paths of the files named in the profiles.
+// Import paths are resolved to directories with go list, run in dir.
+func resolveCoverFiles(dir string, profiles []*cover.Profile) (map[string]string, error) {
+	var pkgs []string
+	for _, p := range profiles {
+		if pkg := coverFilePackage(p.FileName); pkg != "" {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	slices.Sort(pkgs)
+	pkgs = slices.Compact(pkgs)
+	dirs, err := packageDirs(dir, pkgs)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]string, len(profiles))
+	for _, p := range profiles {
+		name := p.FileName
+		switch pkg := coverFilePackage(name); {
+		case pkg != "" && dirs[pkg] != "":
+			paths[name] = filepath.Join(dirs[pkg], path.Base(name))
+		case strings.HasPrefix(name, "_") && filepath.IsAbs(name[1:]):
+			paths[name] = filepath.FromSlash(name[1:])
+		case strings.HasPrefix(name, "."):
+			base := dir
+			if base == "" {
+				base, _ = os.Getwd()
+			}
+			paths[name] = filepath.Join(base, filepath.FromSlash(name))
+		default:
+			paths[name] = filepath.FromSlash(name)
+		}
+	}
+	return paths, nil
+}
+
+// packageDirs returns the directories of the packages with the given
+// import paths, as reported by go list run in dir. Packages that are
+// not found are omitted.
+func packageDirs(dir string, pkgs []string) (map[string]string, error) {
+	dirs := map[string]string{}
+	if len(pkgs) == 0 {
+		return dirs, nil
+	}
+	cmd := exec.Command("go", append([]string{"list", "-e", "-f", "{{.ImportPath}}\t{{.Dir}}", "--"}, pkgs...)...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list: %v: %s", err, stderr.Bytes())
+	}
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		pkg, d, ok := strings.Cut(s.Text(), "\t")
+		if ok && d != "" {
+			dirs[pkg] = d
+		}
+	}
+	return dirs, s.Err()
+}
+
+// coverageReport converts the profiles to a CoverageReport, using
+// paths to map the file names in the profiles to file paths.
+// Blocks with invalid positions, as produced for code with line
+// directives, are skipped.
+func coverageReport(profiles []*cover.Profile, paths map[string]string) *CoverageReport {
+	r := &CoverageReport{Files: []*FileCoverage{}}
+	for _, p := range profiles {
+		r.Mode = p.Mode
+		fc := &FileCoverage{
+			FileName:  p.FileName,
+			Path:      cmp.Or(paths[p.FileName], p.FileName),
+			Package:   coverFilePackage(p.FileName),
+			Covered:   []CoverRange{},
+			Uncovered: []CoverRange{},
+		}
+		for _, b := range p.Blocks {
+			if b.StartLine < 1 || b.StartCol < 1 || b.EndLine < 1 || b.EndCol < 1 {
+				continue
+			}
+			cr := CoverRange{b.StartLine, b.StartCol, b.EndLine, b.EndCol, b.NumStmt, b.Count}
+			fc.Statements += b.NumStmt
+			if b.Count > 0 {
+				fc.CoveredStatements += b.NumStmt
+				fc.Covered = append(fc.Covered, cr)
+			} else {
+				fc.Uncovered = append(fc.Uncovered, cr)
+			}
+		}
+		r.Files = append(r.Files, fc)
+	}
+	return r
+}
+
+func runCover(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: cover [-C dir] [-format json|func|lcov|cobertura] <profile>...")
+	}
+	switch *coverFormat {
+	case "json", "func", "lcov", "cobertura":
+	default:
+		return fmt.Errorf("unknown format %q, want json, func, lcov, or cobertura", *coverFormat)
+	}
+	profiles, err := mergeCoverProfiles(args)
+	if err != nil {
+		return err
+	}
+	paths, err := resolveCoverFiles(*coverDir, profiles)
+	if err != nil {
+		return err
+	}
+	r := coverageReport(profiles, paths)
+	if *coverFormat == "json" {
+		return json.NewEncoder(os.Stdout).Encode(r)
+	}
+
+	fr, err := funcCoverage(r)
+	if err != nil {
+		return err
+	}
+	switch *coverFormat {
+	case "func":
+		return json.NewEncoder(os.Stdout).Encode(fr)
+	case "lcov":
+		return writeLCOV(os.Stdout, r, fr)
+	default:
+		root, err := filepath.Abs(*coverDir)
+		if err != nil {
+			return err
+		}
+		return writeCobertura(os.Stdout, r, fr, root, time.Now())
+	}
+}
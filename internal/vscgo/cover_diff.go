@@ -0,0 +1,226 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	coverDiffFlags   = flag.NewFlagSet("cover-diff", flag.ExitOnError)
+	coverDiffDir     = coverDiffFlags.String("C", "", "directory to run go list in to resolve import paths to directories (default: current directory)")
+	coverDiffBaseRev = coverDiffFlags.String("base-rev", "", "git revision of the source of the base profile, whose lines are matched to those of the working tree, the source of the head profile, through git diff; without it, the profiles are assumed to be of the same source and lines are matched by number")
+)
+
+// CoverageDiff is the difference in coverage between two runs.
+type CoverageDiff struct {
+	Base, Head CoverageSummary
+	Files      []*FileCoverageDiff // files whose coverage changed
+}
+
+// FileCoverageDiff is the difference in coverage of a file.
+// Line numbers refer to the file in the head run.
+type FileCoverageDiff struct {
+	FileName     string
+	Path         string
+	Base, Head   CoverageSummary
+	Delta        float64     // Head.Percent - Base.Percent
+	Lost         []LineRange // lines covered in the base run but not in the head run
+	NewlyCovered []LineRange // lines covered in the head run but not in the base run
+}
+
+// LineRange is a range of lines, inclusive.
+type LineRange struct {
+	Start, End int
+}
+
+// diffHunk is a hunk of a git diff: count lines at start in the old
+// file are replaced by newCount lines at newStart in the new file.
+type diffHunk struct {
+	start, count       int
+	newStart, newCount int
+}
+
+// lineMap maps the lines of a file to those of an older revision,
+// through the hunks of their diff, in order.
+type lineMap []diffHunk
+
+// base returns the line of the old revision that line l of the new
+// revision was, or false if l was added or changed.
+func (m lineMap) base(l int) (int, bool) {
+	offset := 0
+	for _, h := range m {
+		// end is the first line after the hunk; a hunk that only
+		// removes lines does so after line newStart.
+		end := h.newStart + max(h.newCount, 1)
+		if l >= end {
+			offset += h.count - h.newCount
+			continue
+		}
+		if h.newCount > 0 && l >= h.newStart {
+			return 0, false
+		}
+		break
+	}
+	return l + offset, true
+}
+
+var hunkRx = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// gitLineMap returns the line map of the file path in the working tree
+// to its content at the git revision rev.
+func gitLineMap(ctx context.Context, rev, path string) (lineMap, error) {
+	cmd := exec.CommandContext(ctx, "git", "diff", "-U0", "--no-color", "--no-ext-diff", rev, "--", filepath.Base(path))
+	cmd.Dir = filepath.Dir(path)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git diff: %v: %s", err, stderr.Bytes())
+	}
+	m := lineMap{}
+	for line := range strings.Lines(string(out)) {
+		sm := hunkRx.FindStringSubmatch(line)
+		if sm == nil {
+			continue
+		}
+		n := func(s string) int {
+			if s == "" {
+				return 1
+			}
+			v, _ := strconv.Atoi(s)
+			return v
+		}
+		m = append(m, diffHunk{n(sm[1]), n(sm[2]), n(sm[3]), n(sm[4])})
+	}
+	return m, nil
+}
+
+// diffCoverage compares the coverage reports of two runs. Lines of the
+// head run are matched to those of the base run through lineMaps, by
+// file name; lines added or changed in the head run count as not
+// covered in the base run, and files without a line map only have
+// their statement coverage compared. If lineMaps is nil, the runs are
+// assumed to be of the same source, and lines matched by number.
+func diffCoverage(base, head *CoverageReport, lineMaps map[string]lineMap) *CoverageDiff {
+	type pair struct{ base, head *FileCoverage }
+	files := map[string]*pair{}
+	for _, fc := range base.Files {
+		files[fc.FileName] = &pair{base: fc}
+	}
+	for _, fc := range head.Files {
+		if p := files[fc.FileName]; p != nil {
+			p.head = fc
+		} else {
+			files[fc.FileName] = &pair{head: fc}
+		}
+	}
+
+	d := &CoverageDiff{Files: []*FileCoverageDiff{}}
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		p := files[name]
+		fd := &FileCoverageDiff{FileName: name, Lost: []LineRange{}, NewlyCovered: []LineRange{}}
+		baseLines, headLines := map[int]int{}, map[int]int{}
+		if p.base != nil {
+			fd.Path = p.base.Path
+			fd.Base.add(p.base.Statements, p.base.CoveredStatements)
+			baseLines = lineCounts(p.base)
+		}
+		if p.head != nil {
+			fd.Path = p.head.Path
+			fd.Head.add(p.head.Statements, p.head.CoveredStatements)
+			headLines = lineCounts(p.head)
+		}
+		d.Base.add(fd.Base.Statements, fd.Base.CoveredStatements)
+		d.Head.add(fd.Head.Statements, fd.Head.CoveredStatements)
+		fd.Delta = fd.Head.Percent - fd.Base.Percent
+
+		lm, ok := lineMaps[name]
+		if lineMaps != nil && !ok {
+			headLines = nil
+		}
+		var lost, covered []int
+		for _, l := range slices.Sorted(maps.Keys(headLines)) {
+			b := 0
+			if bl, ok := lm.base(l); ok {
+				b = baseLines[bl]
+			}
+			switch h := headLines[l]; {
+			case b > 0 && h == 0:
+				lost = append(lost, l)
+			case b == 0 && h > 0:
+				covered = append(covered, l)
+			}
+		}
+		fd.Lost = lineRanges(lost)
+		fd.NewlyCovered = lineRanges(covered)
+		if len(fd.Lost) > 0 || len(fd.NewlyCovered) > 0 || fd.Base != fd.Head {
+			d.Files = append(d.Files, fd)
+		}
+	}
+	slices.SortStableFunc(d.Files, func(a, b *FileCoverageDiff) int {
+		return cmp.Compare(a.Delta, b.Delta) // regressions first
+	})
+	return d
+}
+
+// lineRanges compacts sorted line numbers into ranges.
+func lineRanges(lines []int) []LineRange {
+	res := []LineRange{}
+	for _, l := range lines {
+		if n := len(res); n > 0 && res[n-1].End == l-1 {
+			res[n-1].End = l
+			continue
+		}
+		res = append(res, LineRange{l, l})
+	}
+	return res
+}
+
+func runCoverDiff(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: cover-diff [-C dir] [-base-rev rev] <base profile> <head profile>")
+	}
+	var reports [2]*CoverageReport
+	for i, f := range args {
+		profiles, err := mergeCoverProfiles([]string{f})
+		if err != nil {
+			return err
+		}
+		paths, err := resolveCoverFiles(*coverDiffDir, profiles)
+		if err != nil {
+			return err
+		}
+		reports[i] = coverageReport(profiles, paths)
+	}
+	var lineMaps map[string]lineMap
+	if *coverDiffBaseRev != "" {
+		lineMaps = map[string]lineMap{}
+		for _, fc := range reports[1].Files {
+			if fc.Path == "" {
+				continue
+			}
+			// Files that cannot be diffed, as outside a
+			// repository, have no line-level changes.
+			if m, err := gitLineMap(context.Background(), *coverDiffBaseRev, fc.Path); err == nil {
+				lineMaps[fc.FileName] = m
+			}
+		}
+	}
+	return json.NewEncoder(os.Stdout).Encode(diffCoverage(reports[0], reports[1], lineMaps))
+}
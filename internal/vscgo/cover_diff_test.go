@@ -0,0 +1,145 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/tools/cover"
+)
+
+func TestDiffCoverage(t *testing.T) {
+	report := func(blocks map[string][]cover.ProfileBlock) *CoverageReport {
+		var ps []*cover.Profile
+		for _, name := range []string{"example.com/m/a.go", "example.com/m/b.go", "example.com/m/c.go"} {
+			if bs, ok := blocks[name]; ok {
+				ps = append(ps, &cover.Profile{FileName: name, Mode: "set", Blocks: bs})
+			}
+		}
+		return coverageReport(ps, nil)
+	}
+	base := report(map[string][]cover.ProfileBlock{
+		"example.com/m/a.go": {
+			{StartLine: 1, StartCol: 1, EndLine: 3, EndCol: 2, NumStmt: 2, Count: 1},
+			{StartLine: 5, StartCol: 1, EndLine: 6, EndCol: 2, NumStmt: 2, Count: 0},
+		},
+		"example.com/m/b.go": {
+			{StartLine: 1, StartCol: 1, EndLine: 2, EndCol: 2, NumStmt: 1, Count: 1},
+		},
+	})
+	head := report(map[string][]cover.ProfileBlock{
+		"example.com/m/a.go": {
+			{StartLine: 1, StartCol: 1, EndLine: 3, EndCol: 2, NumStmt: 2, Count: 0},
+			{StartLine: 5, StartCol: 1, EndLine: 6, EndCol: 2, NumStmt: 2, Count: 1},
+			{StartLine: 8, StartCol: 1, EndLine: 8, EndCol: 9, NumStmt: 1, Count: 0},
+		},
+		"example.com/m/b.go": {
+			{StartLine: 1, StartCol: 1, EndLine: 2, EndCol: 2, NumStmt: 1, Count: 1},
+		},
+		"example.com/m/c.go": {
+			{StartLine: 4, StartCol: 1, EndLine: 4, EndCol: 9, NumStmt: 1, Count: 1},
+		},
+	})
+
+	d := diffCoverage(base, head, nil)
+	if d.Base.Statements != 5 || d.Base.CoveredStatements != 3 || d.Head.Statements != 7 || d.Head.CoveredStatements != 4 {
+		t.Errorf("totals = %+v -> %+v, want 3/5 -> 4/7", d.Base, d.Head)
+	}
+	if len(d.Files) != 2 {
+		t.Fatalf("changed files = %+v, want a.go and c.go", d.Files)
+	}
+	a, c := d.Files[0], d.Files[1]
+	if a.FileName != "example.com/m/a.go" || a.Delta != 40-50 {
+		t.Errorf("first file = %s with delta %v, want a.go with delta -10", a.FileName, a.Delta)
+	}
+	if want := []LineRange{{1, 3}}; !reflect.DeepEqual(a.Lost, want) {
+		t.Errorf("a.go lost lines = %v, want %v", a.Lost, want)
+	}
+	if want := []LineRange{{5, 6}}; !reflect.DeepEqual(a.NewlyCovered, want) {
+		t.Errorf("a.go newly covered lines = %v, want %v", a.NewlyCovered, want)
+	}
+	if c.FileName != "example.com/m/c.go" || c.Delta != 100 || !reflect.DeepEqual(c.NewlyCovered, []LineRange{{4, 4}}) {
+		t.Errorf("c.go diff = %+v, want newly covered line 4", c)
+	}
+}
+
+func TestDiffCoverageMovedLines(t *testing.T) {
+	report := func(blocks ...cover.ProfileBlock) *CoverageReport {
+		return coverageReport([]*cover.Profile{{FileName: "example.com/m/a.go", Mode: "set", Blocks: blocks}}, nil)
+	}
+	base := report(
+		cover.ProfileBlock{StartLine: 3, StartCol: 1, EndLine: 5, EndCol: 2, NumStmt: 2, Count: 1},
+		cover.ProfileBlock{StartLine: 7, StartCol: 1, EndLine: 8, EndCol: 2, NumStmt: 1, Count: 0},
+	)
+	// Two lines are inserted after line 1, and line 8 is changed and
+	// now covered.
+	head := report(
+		cover.ProfileBlock{StartLine: 5, StartCol: 1, EndLine: 7, EndCol: 2, NumStmt: 2, Count: 1},
+		cover.ProfileBlock{StartLine: 9, StartCol: 1, EndLine: 9, EndCol: 2, NumStmt: 1, Count: 0},
+		cover.ProfileBlock{StartLine: 10, StartCol: 1, EndLine: 10, EndCol: 2, NumStmt: 1, Count: 1},
+	)
+	lm := lineMap{{start: 1, count: 0, newStart: 2, newCount: 2}, {start: 8, count: 1, newStart: 10, newCount: 1}}
+	for l, want := range map[int]int{1: 1, 2: 0, 3: 0, 4: 2, 9: 7, 10: 0, 11: 9} {
+		if got, ok := lm.base(l); got != want || ok != (want != 0) {
+			t.Errorf("base line of %d = %d, %v, want %d", l, got, ok, want)
+		}
+	}
+
+	d := diffCoverage(base, head, map[string]lineMap{"example.com/m/a.go": lm})
+	if len(d.Files) != 1 {
+		t.Fatalf("changed files = %+v, want a.go", d.Files)
+	}
+	if a := d.Files[0]; len(a.Lost) != 0 || !reflect.DeepEqual(a.NewlyCovered, []LineRange{{10, 10}}) {
+		t.Errorf("a.go lost %v and newly covered %v, want only line 10 newly covered", a.Lost, a.NewlyCovered)
+	}
+	// Matched by number, the moved lines appear to change coverage.
+	if a := diffCoverage(base, head, nil).Files[0]; !reflect.DeepEqual(a.NewlyCovered, []LineRange{{6, 7}, {10, 10}}) {
+		t.Errorf("a.go newly covered lines matched by number = %v, want 6-7 and 10", a.NewlyCovered)
+	}
+	// Files without a line map only have their statements compared.
+	if a := diffCoverage(base, head, map[string]lineMap{}).Files[0]; len(a.Lost)+len(a.NewlyCovered) != 0 {
+		t.Errorf("a.go without a line map lost %v and newly covered %v, want none", a.Lost, a.NewlyCovered)
+	}
+}
+
+func TestGitLineMap(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir := writeFiles(t, t.TempDir(), map[string]string{
+		"a.go": "package a\n\nfunc f() {\n\tprintln()\n}\n\nfunc g() {}\n",
+	})
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=a", "GIT_AUTHOR_EMAIL=a@example.com", "GIT_COMMITTER_NAME=a", "GIT_COMMITTER_EMAIL=a@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-q", "-m", "initial")
+	src := "package a\n\n// f prints.\n// It is documented.\nfunc f() {\n\tprintln()\n}\n\nfunc g() {}\n"
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte(src), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	lm, err := gitLineMap(context.Background(), "HEAD", filepath.Join(dir, "a.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for l, want := range map[int]int{2: 2, 3: 0, 4: 0, 5: 3, 6: 4, 9: 7} {
+		if got, ok := lm.base(l); got != want || ok != (want != 0) {
+			t.Errorf("base line of %d = %d, %v, want %d", l, got, ok, want)
+		}
+	}
+}
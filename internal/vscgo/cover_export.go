@@ -0,0 +1,396 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FuncCoverageReport is the statement coverage of functions and
+// packages, as reported by go tool cover -func.
+type FuncCoverageReport struct {
+	Mode      string
+	Functions []*FuncCoverage
+	Packages  []*PackageCoverage
+	Total     CoverageSummary
+}
+
+// CoverageSummary is a number of statements and how many are covered.
+type CoverageSummary struct {
+	Statements        int
+	CoveredStatements int
+	Percent           float64
+}
+
+func (s *CoverageSummary) add(stmts, covered int) {
+	s.Statements += stmts
+	s.CoveredStatements += covered
+	s.Percent = 0
+	if s.Statements > 0 {
+		s.Percent = 100 * float64(s.CoveredStatements) / float64(s.Statements)
+	}
+}
+
+// FuncCoverage is the coverage of a function.
+type FuncCoverage struct {
+	Name            string // function name, without receiver, as go tool cover prints it
+	Recv            string `json:",omitempty"` // receiver type of methods, as *T
+	Path            string
+	Package         string
+	Line, Col       int
+	EndLine, EndCol int
+	Count           int // execution count of the function's first block
+	CoverageSummary
+}
+
+// PackageCoverage is the coverage of a package, identified by its
+// import path, or by its directory if unknown.
+type PackageCoverage struct {
+	Package string
+	CoverageSummary
+}
+
+// QualifiedName returns the name of the function qualified by its
+// receiver type, as in (*T).String, which is unique within a package.
+func (f *FuncCoverage) QualifiedName() string {
+	if f.Recv == "" {
+		return f.Name
+	}
+	if strings.HasPrefix(f.Recv, "*") {
+		return "(" + f.Recv + ")." + f.Name
+	}
+	return f.Recv + "." + f.Name
+}
+
+// funcExtent is the position of a function declaration.
+type funcExtent struct {
+	name                string
+	recv                string
+	startLine, startCol int
+	endLine, endCol     int
+}
+
+// fileFuncs returns the extents of the functions declared in the file.
+func fileFuncs(path string) ([]funcExtent, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	var funcs []funcExtent
+	ast.Inspect(f, func(n ast.Node) bool {
+		if fd, ok := n.(*ast.FuncDecl); ok {
+			start, end := fset.Position(fd.Pos()), fset.Position(fd.End())
+			funcs = append(funcs, funcExtent{fd.Name.Name, recvType(fd), start.Line, start.Column, end.Line, end.Column})
+			return false
+		}
+		return true
+	})
+	return funcs, nil
+}
+
+// recvType returns the receiver type of a method, without its type
+// parameters, as *T, or "" for functions.
+func recvType(fd *ast.FuncDecl) string {
+	if fd.Recv == nil || len(fd.Recv.List) != 1 {
+		return ""
+	}
+	typ, star := fd.Recv.List[0].Type, ""
+	if p, ok := typ.(*ast.StarExpr); ok {
+		typ, star = p.X, "*"
+	}
+	switch t := typ.(type) {
+	case *ast.IndexExpr:
+		typ = t.X
+	case *ast.IndexListExpr:
+		typ = t.X
+	}
+	if id, ok := typ.(*ast.Ident); ok {
+		return star + id.Name
+	}
+	return ""
+}
+
+// contains reports whether the block r is within the function.
+func (fe funcExtent) contains(r CoverRange) bool {
+	after := r.StartLine > fe.startLine || r.StartLine == fe.startLine && r.StartCol >= fe.startCol
+	before := r.EndLine < fe.endLine || r.EndLine == fe.endLine && r.EndCol <= fe.endCol
+	return after && before
+}
+
+// funcCoverage computes the coverage of the functions in the report's
+// files, which are parsed to find the functions.
+func funcCoverage(r *CoverageReport) (*FuncCoverageReport, error) {
+	fr := &FuncCoverageReport{Mode: r.Mode, Functions: []*FuncCoverage{}, Packages: []*PackageCoverage{}}
+	pkgs := map[string]*PackageCoverage{}
+	for _, fc := range r.Files {
+		funcs, err := fileFuncs(fc.Path)
+		if err != nil {
+			return nil, err
+		}
+		pkg := cmp.Or(fc.Package, filepath.Dir(fc.Path))
+		blocks := fileBlocks(fc)
+		for _, fe := range funcs {
+			f := &FuncCoverage{
+				Name:    fe.name,
+				Recv:    fe.recv,
+				Path:    fc.Path,
+				Package: pkg,
+				Line:    fe.startLine,
+				Col:     fe.startCol,
+				EndLine: fe.endLine,
+				EndCol:  fe.endCol,
+			}
+			first := true
+			for _, b := range blocks {
+				if !fe.contains(b) {
+					continue
+				}
+				if first {
+					f.Count = b.Count
+					first = false
+				}
+				covered := 0
+				if b.Count > 0 {
+					covered = b.NumStmt
+				}
+				f.add(b.NumStmt, covered)
+			}
+			fr.Functions = append(fr.Functions, f)
+		}
+		pc := pkgs[pkg]
+		if pc == nil {
+			pc = &PackageCoverage{Package: pkg}
+			pkgs[pkg] = pc
+		}
+		pc.add(fc.Statements, fc.CoveredStatements)
+		fr.Total.add(fc.Statements, fc.CoveredStatements)
+	}
+	for _, pkg := range slices.Sorted(maps.Keys(pkgs)) {
+		fr.Packages = append(fr.Packages, pkgs[pkg])
+	}
+	return fr, nil
+}
+
+// fileBlocks returns the covered and uncovered blocks of the file,
+// sorted by position.
+func fileBlocks(fc *FileCoverage) []CoverRange {
+	blocks := slices.Concat(fc.Covered, fc.Uncovered)
+	slices.SortFunc(blocks, func(a, b CoverRange) int {
+		if c := cmp.Compare(a.StartLine, b.StartLine); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.StartCol, b.StartCol)
+	})
+	return blocks
+}
+
+// lineCounts returns the execution count of each line that is part of
+// a block. A line that is part of several blocks, like the line of an
+// if statement and its body, has the largest count of those blocks.
+func lineCounts(fc *FileCoverage) map[int]int {
+	counts := map[int]int{}
+	for _, b := range fileBlocks(fc) {
+		for l := b.StartLine; l <= b.EndLine; l++ {
+			if c, ok := counts[l]; !ok || b.Count > c {
+				counts[l] = b.Count
+			}
+		}
+	}
+	return counts
+}
+
+// funcsByPath groups the functions of a report by file.
+func funcsByPath(fr *FuncCoverageReport) map[string][]*FuncCoverage {
+	m := map[string][]*FuncCoverage{}
+	for _, f := range fr.Functions {
+		m[f.Path] = append(m[f.Path], f)
+	}
+	return m
+}
+
+// writeLCOV writes the coverage in the LCOV tracefile format.
+// See https://manpages.debian.org/lcov/geninfo.1.en.html#TRACEFILE_FORMAT.
+func writeLCOV(w io.Writer, r *CoverageReport, fr *FuncCoverageReport) error {
+	bw := bufio.NewWriter(w)
+	w = bw
+	funcs := funcsByPath(fr)
+	for _, fc := range r.Files {
+		fmt.Fprintf(w, "TN:\nSF:%s\n", fc.Path)
+		hit := 0
+		for _, f := range funcs[fc.Path] {
+			fmt.Fprintf(w, "FN:%d,%d,%s\n", f.Line, f.EndLine, f.QualifiedName())
+		}
+		for _, f := range funcs[fc.Path] {
+			fmt.Fprintf(w, "FNDA:%d,%s\n", f.Count, f.QualifiedName())
+			if f.Count > 0 {
+				hit++
+			}
+		}
+		fmt.Fprintf(w, "FNF:%d\nFNH:%d\n", len(funcs[fc.Path]), hit)
+		counts := lineCounts(fc)
+		hit = 0
+		for _, l := range slices.Sorted(maps.Keys(counts)) {
+			fmt.Fprintf(w, "DA:%d,%d\n", l, counts[l])
+			if counts[l] > 0 {
+				hit++
+			}
+		}
+		fmt.Fprintf(w, "LF:%d\nLH:%d\nend_of_record\n", len(counts), hit)
+	}
+	return bw.Flush()
+}
+
+// Cobertura XML report elements.
+// See https://github.com/cobertura/cobertura/blob/master/cobertura/src/site/htdocs/xml/coverage-04.dtd.
+type (
+	coberturaCoverage struct {
+		XMLName         xml.Name           `xml:"coverage"`
+		LineRate        string             `xml:"line-rate,attr"`
+		BranchRate      string             `xml:"branch-rate,attr"`
+		LinesCovered    int                `xml:"lines-covered,attr"`
+		LinesValid      int                `xml:"lines-valid,attr"`
+		BranchesCovered int                `xml:"branches-covered,attr"`
+		BranchesValid   int                `xml:"branches-valid,attr"`
+		Complexity      string             `xml:"complexity,attr"`
+		Version         string             `xml:"version,attr"`
+		Timestamp       int64              `xml:"timestamp,attr"`
+		Sources         []string           `xml:"sources>source"`
+		Packages        []coberturaPackage `xml:"packages>package"`
+	}
+	coberturaPackage struct {
+		Name       string           `xml:"name,attr"`
+		LineRate   string           `xml:"line-rate,attr"`
+		BranchRate string           `xml:"branch-rate,attr"`
+		Complexity string           `xml:"complexity,attr"`
+		Classes    []coberturaClass `xml:"classes>class"`
+	}
+	coberturaClass struct {
+		Name       string            `xml:"name,attr"`
+		Filename   string            `xml:"filename,attr"`
+		LineRate   string            `xml:"line-rate,attr"`
+		BranchRate string            `xml:"branch-rate,attr"`
+		Complexity string            `xml:"complexity,attr"`
+		Methods    []coberturaMethod `xml:"methods>method"`
+		Lines      []coberturaLine   `xml:"lines>line"`
+	}
+	coberturaMethod struct {
+		Name       string          `xml:"name,attr"`
+		Signature  string          `xml:"signature,attr"`
+		LineRate   string          `xml:"line-rate,attr"`
+		BranchRate string          `xml:"branch-rate,attr"`
+		Complexity string          `xml:"complexity,attr"`
+		Lines      []coberturaLine `xml:"lines>line"`
+	}
+	coberturaLine struct {
+		Number int `xml:"number,attr"`
+		Hits   int `xml:"hits,attr"`
+	}
+)
+
+func lineRate(covered, valid int) string {
+	if valid == 0 {
+		return "1"
+	}
+	return strconv.FormatFloat(float64(covered)/float64(valid), 'f', -1, 64)
+}
+
+// writeCobertura writes the coverage as a Cobertura XML report, with one
+// class per file. File names are relative to root when they are within it.
+func writeCobertura(w io.Writer, r *CoverageReport, fr *FuncCoverageReport, root string, now time.Time) error {
+	funcs := funcsByPath(fr)
+	c := &coberturaCoverage{
+		BranchRate: "0",
+		Complexity: "0",
+		Timestamp:  now.UnixMilli(),
+		Sources:    []string{root},
+	}
+	pkgs := map[string]*coberturaPackage{}
+	pkgLines := map[string][2]int{}
+	for _, fc := range r.Files {
+		pkg := cmp.Or(fc.Package, filepath.Dir(fc.Path))
+		name := fc.Path
+		if rel, err := filepath.Rel(root, fc.Path); err == nil && filepath.IsLocal(rel) {
+			name = filepath.ToSlash(rel)
+		}
+		counts := lineCounts(fc)
+		cl := coberturaClass{
+			Name:       filepath.Base(fc.Path),
+			Filename:   name,
+			BranchRate: "0",
+			Complexity: "0",
+			Methods:    []coberturaMethod{},
+			Lines:      []coberturaLine{},
+		}
+		covered := 0
+		for _, l := range slices.Sorted(maps.Keys(counts)) {
+			cl.Lines = append(cl.Lines, coberturaLine{l, counts[l]})
+			if counts[l] > 0 {
+				covered++
+			}
+		}
+		cl.LineRate = lineRate(covered, len(counts))
+		for _, f := range funcs[fc.Path] {
+			m := coberturaMethod{
+				Name:       f.QualifiedName(),
+				BranchRate: "0",
+				Complexity: "0",
+				Lines:      []coberturaLine{},
+			}
+			mcovered := 0
+			for _, l := range cl.Lines {
+				if l.Number >= f.Line && l.Number <= f.EndLine {
+					m.Lines = append(m.Lines, l)
+					if l.Hits > 0 {
+						mcovered++
+					}
+				}
+			}
+			m.LineRate = lineRate(mcovered, len(m.Lines))
+			cl.Methods = append(cl.Methods, m)
+		}
+
+		p := pkgs[pkg]
+		if p == nil {
+			p = &coberturaPackage{Name: pkg, BranchRate: "0", Complexity: "0"}
+			pkgs[pkg] = p
+		}
+		p.Classes = append(p.Classes, cl)
+		n := pkgLines[pkg]
+		pkgLines[pkg] = [2]int{n[0] + covered, n[1] + len(counts)}
+		c.LinesCovered += covered
+		c.LinesValid += len(counts)
+	}
+	for _, pkg := range slices.Sorted(maps.Keys(pkgs)) {
+		p := pkgs[pkg]
+		p.LineRate = lineRate(pkgLines[pkg][0], pkgLines[pkg][1])
+		c.Packages = append(c.Packages, *p)
+	}
+	c.LineRate = lineRate(c.LinesCovered, c.LinesValid)
+
+	if _, err := io.WriteString(w, xml.Header+`<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">`+"\n"); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	if err := enc.Encode(c); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,185 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"bytes"
+	"encoding/xml"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const coverTestSource = `package m
+
+func Covered(x int) int {
+	if x > 0 {
+		return x
+	}
+	return -x
+}
+
+func Uncovered() {
+	println()
+}
+`
+
+func coverTestReport(t *testing.T) (string, *CoverageReport) {
+	t.Helper()
+	dir := writeFiles(t, t.TempDir(), map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.21\n",
+		"m.go":   coverTestSource,
+		"cover.out": `mode: count
+example.com/m/m.go:3.25,4.11 1 3
+example.com/m/m.go:4.11,6.3 1 2
+example.com/m/m.go:7.2,7.11 1 1
+example.com/m/m.go:10.18,12.2 1 0
+`,
+	})
+	ps, err := mergeCoverProfiles([]string{filepath.Join(dir, "cover.out")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths, err := resolveCoverFiles(dir, ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, coverageReport(ps, paths)
+}
+
+func TestFuncCoverage(t *testing.T) {
+	_, r := coverTestReport(t)
+	fr, err := funcCoverage(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fr.Functions) != 2 {
+		t.Fatalf("got %d functions, want 2", len(fr.Functions))
+	}
+	c, u := fr.Functions[0], fr.Functions[1]
+	if c.Name != "Covered" || c.Statements != 3 || c.CoveredStatements != 3 || c.Percent != 100 || c.Count != 3 {
+		t.Errorf("Covered = %+v, want 3/3 statements, count 3", c)
+	}
+	if u.Name != "Uncovered" || u.Statements != 1 || u.CoveredStatements != 0 || u.Percent != 0 {
+		t.Errorf("Uncovered = %+v, want 0/1 statements", u)
+	}
+	if len(fr.Packages) != 1 || fr.Packages[0].Package != "example.com/m" || fr.Packages[0].Percent != 75 {
+		t.Errorf("packages = %+v, want example.com/m at 75%%", fr.Packages)
+	}
+	if fr.Total.Statements != 4 || fr.Total.CoveredStatements != 3 {
+		t.Errorf("total = %+v, want 3/4", fr.Total)
+	}
+}
+
+func TestWriteLCOV(t *testing.T) {
+	dir, r := coverTestReport(t)
+	fr, err := funcCoverage(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := writeLCOV(&buf, r, fr); err != nil {
+		t.Fatal(err)
+	}
+	want := "TN:\nSF:" + filepath.Join(dir, "m.go") + `
+FN:3,8,Covered
+FN:10,12,Uncovered
+FNDA:3,Covered
+FNDA:0,Uncovered
+FNF:2
+FNH:1
+DA:3,3
+DA:4,3
+DA:5,2
+DA:6,2
+DA:7,1
+DA:10,0
+DA:11,0
+DA:12,0
+LF:8
+LH:5
+end_of_record
+`
+	if got := buf.String(); got != want {
+		t.Errorf("writeLCOV =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteLCOVMethods(t *testing.T) {
+	dir := writeFiles(t, t.TempDir(), map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.21\n",
+		"m.go": `package m
+
+type A struct{}
+
+func (*A) String() string {
+	return "A"
+}
+
+type B[T any] struct{}
+
+func (B[T]) String() string {
+	return "B"
+}
+`,
+		"cover.out": `mode: count
+example.com/m/m.go:5.27,7.2 1 2
+example.com/m/m.go:11.29,13.2 1 0
+`,
+	})
+	ps, err := mergeCoverProfiles([]string{filepath.Join(dir, "cover.out")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths, err := resolveCoverFiles(dir, ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := coverageReport(ps, paths)
+	fr, err := funcCoverage(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := writeLCOV(&buf, r, fr); err != nil {
+		t.Fatal(err)
+	}
+	// Methods of the same name are distinguished by their receivers.
+	for _, want := range []string{"FN:5,7,(*A).String\n", "FN:11,13,B.String\n", "FNDA:2,(*A).String\n", "FNDA:0,B.String\n", "FNH:1\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("writeLCOV output has no %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestWriteCobertura(t *testing.T) {
+	dir, r := coverTestReport(t)
+	fr, err := funcCoverage(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := writeCobertura(&buf, r, fr, dir, time.UnixMilli(1)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "<!DOCTYPE coverage") {
+		t.Errorf("report has no DOCTYPE:\n%s", buf.String())
+	}
+	var c coberturaCoverage
+	if err := xml.Unmarshal(buf.Bytes(), &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.LinesCovered != 5 || c.LinesValid != 8 || c.LineRate != "0.625" {
+		t.Errorf("coverage = %d/%d lines (rate %s), want 5/8 (0.625)", c.LinesCovered, c.LinesValid, c.LineRate)
+	}
+	if len(c.Packages) != 1 || len(c.Packages[0].Classes) != 1 {
+		t.Fatalf("packages = %+v, want one package with one class", c.Packages)
+	}
+	cl := c.Packages[0].Classes[0]
+	if cl.Filename != "m.go" || len(cl.Methods) != 2 || cl.Methods[1].LineRate != "0" {
+		t.Errorf("class = %+v, want m.go with 2 methods, the second uncovered", cl)
+	}
+}
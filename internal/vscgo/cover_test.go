@@ -0,0 +1,121 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeFiles writes the files, keyed by slash-separated paths
+// relative to dir, and returns dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) string {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestMergeCoverProfiles(t *testing.T) {
+	dir := writeFiles(t, t.TempDir(), map[string]string{
+		"run1.out": `mode: atomic
+example.com/m/a.go:3.10,5.2 2 1
+example.com/m/a.go:7.10,9.2 1 0
+example.com/m/a.go:3.10,5.2 2 2
+`,
+		"run2.out": `mode: count
+example.com/m/a.go:7.10,9.2 1 4
+example.com/m/b/b.go:1.1,2.2 1 0
+`,
+		"set.out": `mode: set
+example.com/m/a.go:3.10,5.2 2 1
+`,
+	})
+	ps, err := mergeCoverProfiles([]string{filepath.Join(dir, "run1.out"), filepath.Join(dir, "run2.out")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	type block struct {
+		file  string
+		line  int
+		count int
+	}
+	var got []block
+	for _, p := range ps {
+		if p.Mode != "atomic" {
+			t.Errorf("%s: mode %s, want atomic", p.FileName, p.Mode)
+		}
+		for _, b := range p.Blocks {
+			got = append(got, block{p.FileName, b.StartLine, b.Count})
+		}
+	}
+	want := []block{
+		{"example.com/m/a.go", 3, 3},
+		{"example.com/m/a.go", 7, 4},
+		{"example.com/m/b/b.go", 1, 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("merged blocks = %v, want %v", got, want)
+	}
+
+	if _, err := mergeCoverProfiles([]string{filepath.Join(dir, "run1.out"), filepath.Join(dir, "set.out")}); err == nil {
+		t.Errorf("merging atomic and set profiles succeeded")
+	}
+}
+
+func TestCoverageReport(t *testing.T) {
+	dir := writeFiles(t, t.TempDir(), map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.21\n",
+		"a.go":   "package m\n",
+		"b/b.go": "package b\n",
+		"cover.out": `mode: set
+example.com/m/a.go:3.10,5.2 2 1
+example.com/m/a.go:7.10,9.2 1 0
+example.com/m/a.go:0.0,0.0 1 0
+example.com/m/b/b.go:1.1,2.2 1 0
+_/outside/c.go:1.1,2.2 1 1
+`,
+	})
+	ps, err := mergeCoverProfiles([]string{filepath.Join(dir, "cover.out")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths, err := resolveCoverFiles(dir, ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := coverageReport(ps, paths)
+	if r.Mode != "set" || len(r.Files) != 3 {
+		t.Fatalf("report = %+v, want 3 files in set mode", r)
+	}
+	a := r.Files[1]
+	if want := filepath.Join(dir, "a.go"); a.Path != want || a.Package != "example.com/m" {
+		t.Errorf("a.go resolved to %s in package %s, want %s in example.com/m", a.Path, a.Package, want)
+	}
+	if a.Statements != 3 || a.CoveredStatements != 2 {
+		t.Errorf("a.go statements = %d/%d, want 2/3", a.CoveredStatements, a.Statements)
+	}
+	if want := []CoverRange{{3, 10, 5, 2, 2, 1}}; !reflect.DeepEqual(a.Covered, want) {
+		t.Errorf("a.go covered = %v, want %v", a.Covered, want)
+	}
+	if want := []CoverRange{{7, 10, 9, 2, 1, 0}}; !reflect.DeepEqual(a.Uncovered, want) {
+		t.Errorf("a.go uncovered = %v, want %v", a.Uncovered, want)
+	}
+	if got, want := r.Files[2].Path, filepath.Join(dir, "b", "b.go"); got != want {
+		t.Errorf("b.go resolved to %s, want %s", got, want)
+	}
+	if got, want := r.Files[0].Path, filepath.FromSlash("/outside/c.go"); got != want {
+		t.Errorf("_/outside/c.go resolved to %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,321 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	flakyFlags   = flag.NewFlagSet("flaky", flag.ExitOnError)
+	flakyDir     = flakyFlags.String("C", "", "directory to run go commands in (default: current directory)")
+	flakyRun     = flakyFlags.String("run", "", "run only the tests matching this pattern, as go test's -run")
+	flakyCount   = flakyFlags.Int("count", 20, "number of times to run the tests")
+	flakyRace    = flakyFlags.Bool("race", false, "enable the race detector")
+	flakyCPU     = flakyFlags.String("cpu", "", "comma-separated GOMAXPROCS values to cycle through, as go test's -cpu")
+	flakyShuffle = flakyFlags.Bool("shuffle", false, "shuffle the order of the tests with a different seed in each run")
+	flakyP       = flakyFlags.Int("p", 1, "number of runs to execute in parallel")
+	flakyTimeout = flakyFlags.Duration("timeout", 10*time.Minute, "timeout of each run, as go test's -timeout")
+)
+
+// FlakyReport is the result of running the tests of a package repeatedly.
+type FlakyReport struct {
+	Package string
+	Runs    int
+	Race    bool
+	Tests   []*FlakyTest
+	// PackageFailures counts the runs that failed without a failing
+	// test, as when TestMain exits with a non-zero status.
+	PackageFailures int
+}
+
+// FlakyTest is the result of a test over all runs.
+type FlakyTest struct {
+	Name        string
+	Runs        int
+	Passed      int
+	Failed      int
+	Skipped     int
+	FailureRate float64 // Failed / Runs
+	Failures    []*FlakyFailure
+}
+
+// FlakyFailure is a distinct failure of a test: a failure message or
+// panic, and how to reproduce its first occurrence.
+type FlakyFailure struct {
+	Message string
+	File    string `json:",omitempty"`
+	Line    int    `json:",omitempty"`
+	Count   int
+	Run     int    // first run that failed this way, from 0
+	CPU     string `json:",omitempty"` // GOMAXPROCS of the run
+	Shuffle string `json:",omitempty"` // shuffle seed of the run
+	Command []string
+}
+
+// flakyRunConfig is the configuration of one run.
+type flakyRunConfig struct {
+	cpu     string
+	shuffle string
+}
+
+// flakyConfigs returns the configuration of each of count runs: the
+// GOMAXPROCS values are cycled through, and each run with shuffling
+// gets a new seed.
+func flakyConfigs(count int, cpus []string, shuffle bool, seed func() int64) []flakyRunConfig {
+	cfgs := make([]flakyRunConfig, count)
+	for i := range cfgs {
+		if len(cpus) > 0 {
+			cfgs[i].cpu = cpus[i%len(cpus)]
+		}
+		if shuffle {
+			cfgs[i].shuffle = strconv.FormatInt(seed(), 10)
+		}
+	}
+	return cfgs
+}
+
+// flakyTestResult is the result of a test in one run.
+type flakyTestResult struct {
+	result  string
+	failure *TestFailure // the failure message, for failed tests
+}
+
+// runFlakyOnce runs the test binary bin once in dir through test2json,
+// and returns the result of each test, and whether the run failed.
+func runFlakyOnce(ctx context.Context, pkg, dir, bin, run string, timeout time.Duration, cfg flakyRunConfig) (map[string]*flakyTestResult, bool, error) {
+	args := []string{"tool", "test2json", "-p", pkg, bin, "-test.v=test2json", "-test.count=1"}
+	if run != "" {
+		args = append(args, "-test.run="+run)
+	}
+	if timeout > 0 {
+		args = append(args, "-test.timeout="+timeout.String())
+	}
+	if cfg.cpu != "" {
+		args = append(args, "-test.cpu="+cfg.cpu)
+	}
+	if cfg.shuffle != "" {
+		args = append(args, "-test.shuffle="+cfg.shuffle)
+	}
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, false, fmt.Errorf("running %s: %v: %s", bin, err, stderr.Bytes())
+	}
+
+	results := map[string]*flakyTestResult{}
+	pkgFailed := err != nil
+	err = readTestEvents(bytes.NewReader(out), func(e *TestEvent) error {
+		if e.Test == "" {
+			if e.Kind == "fail" {
+				pkgFailed = true
+			}
+			return nil
+		}
+		r := results[e.Test]
+		if r == nil {
+			r = &flakyTestResult{}
+			results[e.Test] = r
+		}
+		switch e.Kind {
+		case "pass", "skip":
+			r.result = e.Kind
+		case "fail":
+			r.result = "fail"
+			if r.failure == nil && len(e.Failures) > 0 {
+				r.failure = e.Failures[0]
+			}
+		case "panic":
+			r.result = "fail"
+			r.failure = &TestFailure{File: filepath.Base(e.Panic.File), Line: e.Panic.Line, Message: "panic: " + e.Panic.Message}
+		}
+		return nil
+	})
+	return results, pkgFailed, err
+}
+
+// flakyReport aggregates the results of the runs.
+func flakyReport(pkg, run string, race bool, cfgs []flakyRunConfig, runs []map[string]*flakyTestResult, failedRuns []bool) *FlakyReport {
+	rep := &FlakyReport{Package: pkg, Runs: len(runs), Race: race, Tests: []*FlakyTest{}}
+	tests := map[string]*FlakyTest{}
+	failures := map[string]map[string]*FlakyFailure{} // test -> message key -> failure
+	for i, results := range runs {
+		anyFailed := false
+		for _, name := range slices.Sorted(maps.Keys(results)) {
+			r := results[name]
+			t := tests[name]
+			if t == nil {
+				t = &FlakyTest{Name: name, Failures: []*FlakyFailure{}}
+				tests[name] = t
+				failures[name] = map[string]*FlakyFailure{}
+				rep.Tests = append(rep.Tests, t)
+			}
+			t.Runs++
+			switch r.result {
+			case "pass":
+				t.Passed++
+				continue
+			case "skip":
+				t.Skipped++
+				continue
+			}
+			// A test that did not finish, as when another test panicked,
+			// failed too.
+			t.Failed++
+			anyFailed = true
+			f := r.failure
+			if f == nil {
+				f = &TestFailure{Message: "failed without a message"}
+			}
+			key := fmt.Sprintf("%s:%d: %s", f.File, f.Line, f.Message)
+			ff := failures[name][key]
+			if ff == nil {
+				ff = &FlakyFailure{
+					Message: f.Message,
+					File:    f.File,
+					Line:    f.Line,
+					Run:     i,
+					CPU:     cfgs[i].cpu,
+					Shuffle: cfgs[i].shuffle,
+					Command: flakyCommand(pkg, run, race, cfgs[i]),
+				}
+				failures[name][key] = ff
+				t.Failures = append(t.Failures, ff)
+			}
+			ff.Count++
+		}
+		if failedRuns[i] && !anyFailed {
+			rep.PackageFailures++
+		}
+	}
+	for _, t := range rep.Tests {
+		t.FailureRate = float64(t.Failed) / float64(t.Runs)
+	}
+	// Flakiest first.
+	slices.SortStableFunc(rep.Tests, func(a, b *FlakyTest) int {
+		return cmp.Or(cmp.Compare(b.FailureRate, a.FailureRate), cmp.Compare(a.Name, b.Name))
+	})
+	return rep
+}
+
+// flakyCommand returns the go test command that repeats a run with the
+// given configuration. It runs the same tests, not only the failed one,
+// since a failure may depend on the tests that ran before.
+func flakyCommand(pkg, run string, race bool, cfg flakyRunConfig) []string {
+	cmd := []string{"go", "test", "-count=1"}
+	if run != "" {
+		cmd = append(cmd, "-run="+run)
+	}
+	if race {
+		cmd = append(cmd, "-race")
+	}
+	if cfg.cpu != "" {
+		cmd = append(cmd, "-cpu="+cfg.cpu)
+	}
+	if cfg.shuffle != "" {
+		cmd = append(cmd, "-shuffle="+cfg.shuffle)
+	}
+	return append(cmd, pkg)
+}
+
+// detectFlakes builds the tests of pkg and runs them count times.
+func detectFlakes(ctx context.Context, dir, pkg, run string, race bool, cfgs []flakyRunConfig, parallel int, timeout time.Duration) (*FlakyReport, error) {
+	pkgDir, err := goList(ctx, dir, "{{.Dir}}", pkg)
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := os.MkdirTemp("", "vscgo-flaky-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	bin := filepath.Join(tmp, "pkg.test")
+	if runtime.GOOS == "windows" {
+		bin += ".exe"
+	}
+	args := []string{"test", "-c", "-o", bin}
+	if race {
+		args = append(args, "-race")
+	}
+	cmd := exec.CommandContext(ctx, "go", append(args, pkg)...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("go test -c: %v: %s", err, out)
+	}
+
+	runs := make([]map[string]*flakyTestResult, len(cfgs))
+	failed := make([]bool, len(cfgs))
+	errs := make([]error, len(cfgs))
+	sem := make(chan struct{}, max(parallel, 1))
+	var wg sync.WaitGroup
+	for i, cfg := range cfgs {
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			runs[i], failed[i], errs[i] = runFlakyOnce(ctx, pkg, pkgDir, bin, run, timeout, cfg)
+		})
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return flakyReport(pkg, run, race, cfgs, runs, failed), nil
+}
+
+func runFlaky(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: flaky [flags] <package> [flags]")
+	}
+	// Allow the flags to follow the package, as in go test.
+	pkg := args[0]
+	if err := flakyFlags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if flakyFlags.NArg() > 0 {
+		return fmt.Errorf("usage: flaky [flags] <package> [flags]")
+	}
+	if *flakyCount < 1 {
+		return fmt.Errorf("-count must be positive")
+	}
+	var cpus []string
+	for c := range strings.SplitSeq(*flakyCPU, ",") {
+		if c = strings.TrimSpace(c); c == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(c); err != nil || n < 1 {
+			return fmt.Errorf("invalid -cpu value %q", c)
+		}
+		cpus = append(cpus, c)
+	}
+
+	cfgs := flakyConfigs(*flakyCount, cpus, *flakyShuffle, rand.Int64)
+	rep, err := detectFlakes(context.Background(), *flakyDir, pkg, *flakyRun, *flakyRace, cfgs, *flakyP, *flakyTimeout)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(os.Stdout).Encode(rep)
+}
@@ -0,0 +1,78 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestFlakyConfigs(t *testing.T) {
+	seed := int64(0)
+	cfgs := flakyConfigs(5, []string{"1", "4"}, true, func() int64 { seed++; return seed })
+	want := []flakyRunConfig{{"1", "1"}, {"4", "2"}, {"1", "3"}, {"4", "4"}, {"1", "5"}}
+	if !slices.Equal(cfgs, want) {
+		t.Errorf("flakyConfigs = %v, want %v", cfgs, want)
+	}
+}
+
+func TestDetectFlakes(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and runs tests")
+	}
+	counter := t.TempDir()
+	dir := writeFiles(t, t.TempDir(), map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.21\n",
+		"m_test.go": fmt.Sprintf(`package m
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestFlaky fails in every other run.
+func TestFlaky(t *testing.T) {
+	f := filepath.Join(%q, "count")
+	data, _ := os.ReadFile(f)
+	data = append(data, 'x')
+	os.WriteFile(f, data, 0666)
+	if len(data)%%2 == 0 {
+		t.Errorf("even run %%d", 2)
+	}
+}
+
+func TestStable(t *testing.T) {}
+`, counter),
+	})
+	cfgs := flakyConfigs(4, []string{"1", "2"}, true, func() int64 { return 42 })
+	rep, err := detectFlakes(context.Background(), dir, ".", "", false, cfgs, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rep.Runs != 4 || len(rep.Tests) != 2 {
+		t.Fatalf("report = %+v, want 4 runs of 2 tests", rep)
+	}
+	flaky, stable := rep.Tests[0], rep.Tests[1]
+	if flaky.Name != "TestFlaky" || flaky.Passed != 2 || flaky.Failed != 2 || flaky.FailureRate != 0.5 {
+		t.Errorf("TestFlaky = %+v, want 2 of 4 runs failed", flaky)
+	}
+	if len(flaky.Failures) != 1 {
+		t.Fatalf("TestFlaky failures = %+v, want 1", flaky.Failures)
+	}
+	// Runs may execute in any order.
+	f := flaky.Failures[0]
+	if f.Message != "even run 2" || f.File != "m_test.go" || f.Count != 2 || f.CPU != cfgs[f.Run].cpu || f.Shuffle != "42" {
+		t.Errorf("TestFlaky failure = %+v, want %q at m_test.go, twice, with the configuration of run %d", f, "even run 2", f.Run)
+	}
+	if want := []string{"go", "test", "-count=1", "-cpu=" + f.CPU, "-shuffle=42", "."}; !slices.Equal(f.Command, want) {
+		t.Errorf("command = %q, want %q", f.Command, want)
+	}
+	if stable.Name != "TestStable" || stable.Passed != 4 || stable.FailureRate != 0 {
+		t.Errorf("TestStable = %+v, want 4 passes", stable)
+	}
+}
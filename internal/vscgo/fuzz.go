@@ -0,0 +1,337 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	fuzzFlags    = flag.NewFlagSet("fuzz", flag.ExitOnError)
+	fuzzDir      = fuzzFlags.String("C", "", "directory to run go test in (default: current directory)")
+	fuzzTime     = fuzzFlags.String("fuzztime", "1m", "fuzzing budget, as go test's -fuzztime: a duration such as 30s, or a number of executions such as 10000x")
+	fuzzParallel = fuzzFlags.Int("parallel", 0, "number of fuzzing processes, as go test's -parallel (default: GOMAXPROCS)")
+)
+
+// FuzzEvent is an event of a fuzzing session. Kind is one of:
+//
+//	start      - fuzzing is about to start
+//	baseline   - the corpus is being run to gather baseline coverage;
+//	             Ran of Total entries have run
+//	progress   - periodic statistics of the fuzzing processes
+//	minimizing - a failing input is being minimized
+//	output     - other output of the fuzz test, such as its logs
+//	crash      - fuzzing found a failing input, described by Crash
+//	done       - fuzzing ended; Result is pass or fail
+type FuzzEvent struct {
+	Kind             string
+	Package          string         `json:",omitempty"`
+	Fuzz             string         `json:",omitempty"` // for start events
+	Elapsed          float64        `json:",omitempty"` // seconds since fuzzing started, as reported by go test
+	Ran              int            `json:",omitempty"` // for baseline events
+	Total            int            `json:",omitempty"` // for baseline events
+	Workers          int            `json:",omitempty"` // for the baseline event that starts fuzzing
+	Execs            int64          `json:",omitempty"` // for progress events
+	ExecsPerSec      float64        `json:",omitempty"` // for progress events, since the last one
+	NewInteresting   int            `json:",omitempty"` // for progress events: inputs that expanded coverage
+	TotalInteresting int            `json:",omitempty"` // for progress events: the corpus size
+	Output           string         `json:",omitempty"`
+	Crash            *FuzzCrash     `json:",omitempty"`
+	Result           string         `json:",omitempty"`
+	Failures         []*TestFailure `json:",omitempty"` // for done events of failures that are not crashes, such as build errors
+}
+
+// FuzzCrash describes a failing input and how the fuzz test failed
+// with it.
+//
+// Fuzzing writes a new failing input to the package's testdata
+// directory, and File is its path. A failing seed corpus entry is not
+// written: Entry names it, and File is set only if it is a file.
+type FuzzCrash struct {
+	Entry    string           // the corpus entry, such as FuzzFoo/582528ddfad69eb5
+	File     string           `json:",omitempty"`
+	Input    *FuzzCorpusEntry `json:",omitempty"` // the decoded file
+	Failures []*TestFailure   `json:",omitempty"`
+	Panic    *TestPanic       `json:",omitempty"`
+	Rerun    []string         // the go test command that runs the fuzz test with this input only
+}
+
+var (
+	fuzzBaselineRx   = regexp.MustCompile(`^fuzz: elapsed: (\S+), gathering baseline coverage: (\d+)/(\d+) completed(?:, now fuzzing with (\d+) workers)?$`)
+	fuzzProgressRx   = regexp.MustCompile(`^fuzz: elapsed: (\S+), execs: (\d+) \((\S+)/sec\)(?:, new interesting: (\d+) \(total: (\d+)\))?$`)
+	fuzzMinimizingRx = regexp.MustCompile(`^fuzz: elapsed: (\S+), minimizing$`)
+	fuzzCrashRx      = regexp.MustCompile(`^\s*Failing input written to (.+)$`)
+	fuzzSeedRx       = regexp.MustCompile(`^\s*failure while testing seed corpus entry: (\S+)$`)
+	// fuzzStackFileRx matches the file:line line of a stack frame
+	// whose indentation was trimmed.
+	fuzzStackFileRx = regexp.MustCompile(`^\S.*\.go:\d+(?: \+0x[0-9a-f]+)?$`)
+)
+
+// fuzzSession follows the events of go test -fuzz for a fuzz test.
+type fuzzSession struct {
+	pkg  string // import path
+	dir  string // package directory, which crasher paths are relative to
+	fuzz string
+
+	entry    string // the failing corpus entry, if any
+	crasher  string // the file of the failing input, if any
+	failures []*TestFailure
+	panic    *TestPanic
+	result   string
+	build    []*TestFailure
+}
+
+// process returns the FuzzEvent for a test event, if any.
+func (s *fuzzSession) process(e *TestEvent) *FuzzEvent {
+	switch e.Kind {
+	case "output":
+		return s.output(strings.TrimSuffix(e.Output, "\n"))
+	case "fail":
+		if e.Test == s.fuzz {
+			s.failures = e.Failures
+		}
+		if e.Test == "" {
+			s.result = "fail"
+		}
+	case "pass", "skip":
+		if e.Test == "" {
+			s.result = "pass"
+		}
+	case "panic":
+		// The fuzzing process crashed, rather than the fuzz
+		// function panicking.
+		s.panic = e.Panic
+	case "build-fail":
+		s.build = append(s.build, e.Failures...)
+		if len(e.Failures) == 0 {
+			s.build = append(s.build, &TestFailure{Message: strings.TrimSpace(e.Output)})
+		}
+	}
+	return nil
+}
+
+// output returns the FuzzEvent for a line of output.
+func (s *fuzzSession) output(line string) *FuzzEvent {
+	if m := fuzzBaselineRx.FindStringSubmatch(line); m != nil {
+		e := &FuzzEvent{Kind: "baseline", Package: s.pkg, Elapsed: fuzzElapsed(m[1])}
+		e.Ran, _ = strconv.Atoi(m[2])
+		e.Total, _ = strconv.Atoi(m[3])
+		e.Workers, _ = strconv.Atoi(m[4])
+		return e
+	}
+	if m := fuzzProgressRx.FindStringSubmatch(line); m != nil {
+		e := &FuzzEvent{Kind: "progress", Package: s.pkg, Elapsed: fuzzElapsed(m[1])}
+		e.Execs, _ = strconv.ParseInt(m[2], 10, 64)
+		// The rate is NaN or infinite if no time has passed,
+		// which JSON cannot represent.
+		if r, err := strconv.ParseFloat(m[3], 64); err == nil && !math.IsNaN(r) && !math.IsInf(r, 0) {
+			e.ExecsPerSec = r
+		}
+		e.NewInteresting, _ = strconv.Atoi(m[4])
+		e.TotalInteresting, _ = strconv.Atoi(m[5])
+		return e
+	}
+	if m := fuzzMinimizingRx.FindStringSubmatch(line); m != nil {
+		return &FuzzEvent{Kind: "minimizing", Package: s.pkg, Elapsed: fuzzElapsed(m[1])}
+	}
+	if m := fuzzCrashRx.FindStringSubmatch(line); m != nil {
+		s.crasher = m[1]
+		s.entry = s.fuzz + "/" + filepath.Base(m[1])
+	} else if m := fuzzSeedRx.FindStringSubmatch(line); m != nil {
+		s.entry = m[1]
+	}
+	if strings.HasPrefix(line, "fuzz: ") || strings.TrimSpace(line) == "" {
+		return nil
+	}
+	return &FuzzEvent{Kind: "output", Package: s.pkg, Output: line + "\n"}
+}
+
+// fuzzElapsed converts an elapsed time printed by go test to seconds.
+func fuzzElapsed(s string) float64 {
+	d, _ := time.ParseDuration(s)
+	return d.Seconds()
+}
+
+// crash returns the crash event, if a failing input was found.
+func (s *fuzzSession) crash() *FuzzCrash {
+	if s.entry == "" {
+		return nil
+	}
+	c := &FuzzCrash{
+		Entry: s.entry,
+		Rerun: []string{"go", "test", "-run=" + testPathPattern(s.entry), s.pkg},
+	}
+	file := s.crasher
+	if file == "" {
+		// A seed corpus entry is either added with F.Add, or a file
+		// in testdata.
+		file = filepath.Join("testdata", "fuzz", filepath.FromSlash(s.entry))
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(s.dir, file)
+	}
+	if input, err := readFuzzCorpusEntry(file); err == nil {
+		input.Fuzz = s.fuzz
+		c.File, c.Input = file, input
+	}
+
+	// While fuzzing, the testing package recovers panics of the fuzz
+	// function and reports them as errors whose message is the panic
+	// value and stack.
+	for _, f := range s.failures {
+		msg, ok := strings.CutPrefix(f.Message, "panic: ")
+		if !ok || c.Panic != nil {
+			c.Failures = append(c.Failures, f)
+			continue
+		}
+		c.Panic = fuzzPanic(msg)
+	}
+	if c.Panic == nil {
+		c.Panic = s.panic
+	}
+	return c
+}
+
+// fuzzPanic parses the message of a panic recovered by the testing
+// package: the panic value, then the stack of the fuzz function's
+// goroutine. The message lost the indentation of the stack, which is
+// restored for locatePanic.
+func fuzzPanic(msg string) *TestPanic {
+	value, stack, _ := strings.Cut(msg, "\n")
+	if i := strings.Index(value, " [recovered"); i >= 0 {
+		value = value[:i]
+	}
+	lines := strings.Split(stack, "\n")
+	for i, l := range lines {
+		if fuzzStackFileRx.MatchString(l) {
+			lines[i] = "\t" + l
+		}
+	}
+	p := &TestPanic{Message: value, Stack: "panic: " + value + "\n\n" + strings.Join(lines, "\n")}
+	locatePanic(p)
+	return p
+}
+
+// done returns the final events of the session.
+func (s *fuzzSession) done() []*FuzzEvent {
+	var evs []*FuzzEvent
+	c := s.crash()
+	if c != nil {
+		evs = append(evs, &FuzzEvent{Kind: "crash", Package: s.pkg, Crash: c})
+	}
+	e := &FuzzEvent{Kind: "done", Package: s.pkg, Result: s.result}
+	if e.Result == "" || c != nil {
+		e.Result = "fail"
+	}
+	if c == nil {
+		e.Failures = append(s.build, s.failures...)
+	}
+	return append(evs, e)
+}
+
+// superviseFuzz fuzzes the fuzz test of pkg within the budget given as
+// go test's -fuzztime, and writes its events to w, one JSON object per
+// line. It reports whether fuzzing failed.
+func superviseFuzz(ctx context.Context, dir, pkg, fuzz, budget string, parallel int, w io.Writer) (bool, error) {
+	out, err := goList(ctx, dir, "{{.ImportPath}}\n{{.Dir}}", pkg)
+	if err != nil {
+		return false, err
+	}
+	s := &fuzzSession{fuzz: fuzz}
+	s.pkg, s.dir, _ = strings.Cut(out, "\n")
+
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(&FuzzEvent{Kind: "start", Package: s.pkg, Fuzz: fuzz}); err != nil {
+		return false, err
+	}
+	args := []string{"test", "-json", "-run=^$", "-fuzz=^" + regexp.QuoteMeta(fuzz) + "$"}
+	if budget != "" {
+		args = append(args, "-fuzztime="+budget)
+	}
+	if parallel > 0 {
+		args = append(args, "-parallel="+strconv.Itoa(parallel))
+	}
+	cmd := exec.CommandContext(ctx, "go", append(args, pkg)...)
+	cmd.Dir = dir
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return false, err
+	}
+	if err := cmd.Start(); err != nil {
+		return false, err
+	}
+	err = readTestEvents(stdout, func(e *TestEvent) error {
+		if fe := s.process(e); fe != nil {
+			return enc.Encode(fe)
+		}
+		return nil
+	})
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return false, err
+	}
+	err = cmd.Wait()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return false, err
+	}
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	failed := false
+	for _, e := range s.done() {
+		failed = e.Result == "fail"
+		if err := enc.Encode(e); err != nil {
+			return false, err
+		}
+	}
+	return failed, nil
+}
+
+// validFuzzTime reports whether s is a valid -fuzztime value.
+func validFuzzTime(s string) bool {
+	if n, ok := strings.CutSuffix(s, "x"); ok {
+		i, err := strconv.ParseInt(n, 10, 64)
+		return err == nil && i > 0
+	}
+	d, err := time.ParseDuration(s)
+	return err == nil && d > 0
+}
+
+func runFuzz(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: fuzz [flags] <package> <fuzz test>")
+	}
+	if *fuzzTime != "" && !validFuzzTime(*fuzzTime) {
+		return fmt.Errorf("invalid -fuzztime %q: want a duration or a number of executions such as 1000x", *fuzzTime)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	failed, err := superviseFuzz(ctx, *fuzzDir, args[0], args[1], *fuzzTime, *fuzzParallel, os.Stdout)
+	if err != nil {
+		return err
+	}
+	if failed {
+		return fmt.Errorf("%s failed", args[1])
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fuzzEventStream is the output of go test -json -fuzz for a fuzz test
+// that panics, with most of the stack elided.
+const fuzzEventStream = `{"Action":"start","Package":"example.com/m"}
+{"Action":"run","Package":"example.com/m","Test":"FuzzX"}
+{"Action":"output","Package":"example.com/m","Test":"FuzzX","Output":"=== RUN   FuzzX\n","OutputType":"frame"}
+{"Action":"output","Package":"example.com/m","Test":"FuzzX","Output":"fuzz: elapsed: 0s, gathering baseline coverage: 0/2 completed\n"}
+{"Action":"output","Package":"example.com/m","Test":"FuzzX","Output":"fuzz: elapsed: 0s, gathering baseline coverage: 2/2 completed, now fuzzing with 4 workers\n"}
+{"Action":"output","Package":"example.com/m","Test":"FuzzX","Output":"fuzz: elapsed: 3s, execs: 123456 (41152/sec), new interesting: 3 (total: 5)\n"}
+{"Action":"output","Package":"example.com/m","Test":"FuzzX","Output":"fuzz: minimizing 31-byte failing input file\n"}
+{"Action":"output","Package":"example.com/m","Test":"FuzzX","Output":"fuzz: elapsed: 4s, minimizing\n"}
+{"Action":"output","Package":"example.com/m","Test":"FuzzX","Output":"--- FAIL: FuzzX (4.01s)\n","OutputType":"frame"}
+{"Action":"output","Package":"example.com/m","Test":"FuzzX","Output":"    --- FAIL: FuzzX (0.00s)\n"}
+{"Action":"output","Package":"example.com/m","Test":"FuzzX","Output":"        testing.go:2076: panic: boom\n"}
+{"Action":"output","Package":"example.com/m","Test":"FuzzX","Output":"            goroutine 27 [running]:\n"}
+{"Action":"output","Package":"example.com/m","Test":"FuzzX","Output":"            runtime/debug.Stack()\n"}
+{"Action":"output","Package":"example.com/m","Test":"FuzzX","Output":"            \t/usr/local/go/src/runtime/debug/stack.go:26 +0x9b\n"}
+{"Action":"output","Package":"example.com/m","Test":"FuzzX","Output":"            testing.tRunner.func1()\n"}
+{"Action":"output","Package":"example.com/m","Test":"FuzzX","Output":"            \t/usr/local/go/src/testing/testing.go:2076 +0x1b0\n"}
+{"Action":"output","Package":"example.com/m","Test":"FuzzX","Output":"            panic({0x83b6c0?, 0x65fc40?})\n"}
+{"Action":"output","Package":"example.com/m","Test":"FuzzX","Output":"            \t/usr/local/go/src/runtime/panic.go:859 +0x125\n"}
+{"Action":"output","Package":"example.com/m","Test":"FuzzX","Output":"            example.com/m.FuzzX.func1(0x0?, {0x6698e0, 0x1})\n"}
+{"Action":"output","Package":"example.com/m","Test":"FuzzX","Output":"            \t/src/m/m_test.go:9 +0xf0\n"}
+{"Action":"output","Package":"example.com/m","Test":"FuzzX","Output":"            \n"}
+{"Action":"output","Package":"example.com/m","Test":"FuzzX","Output":"    \n"}
+{"Action":"output","Package":"example.com/m","Test":"FuzzX","Output":"    Failing input written to testdata/fuzz/FuzzX/771e938e4458e983\n"}
+{"Action":"output","Package":"example.com/m","Test":"FuzzX","Output":"    To re-run:\n"}
+{"Action":"output","Package":"example.com/m","Test":"FuzzX","Output":"    go test -run=FuzzX/771e938e4458e983\n"}
+{"Action":"fail","Package":"example.com/m","Test":"FuzzX","Elapsed":4.01}
+{"Action":"output","Package":"example.com/m","Output":"FAIL\n","OutputType":"frame"}
+{"Action":"output","Package":"example.com/m","Output":"FAIL\texample.com/m\t4.015s\n","OutputType":"frame"}
+{"Action":"fail","Package":"example.com/m","Elapsed":4.015}
+`
+
+func TestFuzzSession(t *testing.T) {
+	dir := writeFiles(t, t.TempDir(), map[string]string{
+		"testdata/fuzz/FuzzX/771e938e4458e983": "go test fuzz v1\nstring(\"0\")\n",
+	})
+	s := &fuzzSession{pkg: "example.com/m", dir: dir, fuzz: "FuzzX"}
+	var evs []*FuzzEvent
+	err := readTestEvents(strings.NewReader(fuzzEventStream), func(e *TestEvent) error {
+		if fe := s.process(e); fe != nil {
+			evs = append(evs, fe)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	evs = append(evs, s.done()...)
+
+	var kinds []string
+	for _, e := range evs {
+		if e.Kind != "output" {
+			kinds = append(kinds, e.Kind)
+		}
+	}
+	if want := []string{"baseline", "baseline", "progress", "minimizing", "crash", "done"}; !slices.Equal(kinds, want) {
+		t.Fatalf("event kinds = %q, want %q", kinds, want)
+	}
+	if e := evs[1]; e.Ran != 2 || e.Total != 2 || e.Workers != 4 {
+		t.Errorf("baseline event = %+v, want 2/2 with 4 workers", e)
+	}
+	if e := evs[2]; e.Elapsed != 3 || e.Execs != 123456 || e.ExecsPerSec != 41152 || e.NewInteresting != 3 || e.TotalInteresting != 5 {
+		t.Errorf("progress event = %+v", e)
+	}
+
+	c := evs[len(evs)-2].Crash
+	if want := filepath.Join(dir, "testdata", "fuzz", "FuzzX", "771e938e4458e983"); c.Entry != "FuzzX/771e938e4458e983" || c.File != want {
+		t.Errorf("crash entry %s in %s, want FuzzX/771e938e4458e983 in %s", c.Entry, c.File, want)
+	}
+	if c.Input == nil || len(c.Input.Values) != 1 || c.Input.Values[0].Value != "0" {
+		t.Errorf("crash input = %+v, want string(\"0\")", c.Input)
+	}
+	if p := c.Panic; p == nil || p.Message != "boom" || p.Function != "example.com/m.FuzzX.func1" || p.File != "/src/m/m_test.go" || p.Line != 9 {
+		t.Errorf("crash panic = %+v, want boom at /src/m/m_test.go:9", p)
+	}
+	if len(c.Failures) != 0 {
+		t.Errorf("crash failures = %+v, want none but the panic", c.Failures)
+	}
+	if want := []string{"go", "test", "-run=^FuzzX$/^771e938e4458e983$", "example.com/m"}; !slices.Equal(c.Rerun, want) {
+		t.Errorf("rerun command = %q, want %q", c.Rerun, want)
+	}
+	if e := evs[len(evs)-1]; e.Result != "fail" {
+		t.Errorf("done event = %+v, want a failure", e)
+	}
+}
+
+func TestSuperviseFuzz(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and fuzzes tests")
+	}
+	dir := writeFiles(t, t.TempDir(), map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.21\n",
+		"m_test.go": `package m
+
+import "testing"
+
+func FuzzOK(f *testing.F) {
+	f.Add(1)
+	f.Fuzz(func(t *testing.T, n int) {})
+}
+
+func FuzzCrash(f *testing.F) {
+	f.Add("seed")
+	f.Fuzz(func(t *testing.T, s string) {
+		if s != "seed" {
+			panic("boom")
+		}
+	})
+}
+`,
+	})
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	failed, err := superviseFuzz(ctx, dir, ".", "FuzzOK", "200x", 1, &buf)
+	if err != nil || failed {
+		t.Fatalf("fuzzing FuzzOK: failed %v, %v; output:\n%s", failed, err, buf.Bytes())
+	}
+	evs := decodeFuzzEvents(t, &buf)
+	if e := evs[0]; e.Kind != "start" || e.Package != "example.com/m" || e.Fuzz != "FuzzOK" {
+		t.Errorf("first event = %+v, want start of FuzzOK", e)
+	}
+	if e := evs[len(evs)-1]; e.Kind != "done" || e.Result != "pass" {
+		t.Errorf("last event = %+v, want pass", e)
+	}
+	if !slices.ContainsFunc(evs, func(e *FuzzEvent) bool { return e.Kind == "progress" && e.Execs == 200 }) {
+		t.Errorf("no progress event for 200 executions")
+	}
+
+	buf.Reset()
+	failed, err = superviseFuzz(ctx, dir, ".", "FuzzCrash", "10s", 1, &buf)
+	if err != nil || !failed {
+		t.Fatalf("fuzzing FuzzCrash: failed %v, %v; output:\n%s", failed, err, buf.Bytes())
+	}
+	evs = decodeFuzzEvents(t, &buf)
+	i := slices.IndexFunc(evs, func(e *FuzzEvent) bool { return e.Kind == "crash" })
+	if i < 0 {
+		t.Fatalf("no crash event in:\n%s", buf.Bytes())
+	}
+	c := evs[i].Crash
+	if filepath.Dir(c.File) != filepath.Join(dir, "testdata", "fuzz", "FuzzCrash") || c.Input == nil || len(c.Input.Values) != 1 {
+		t.Errorf("crash input %s = %+v, want a file in testdata/fuzz/FuzzCrash", c.File, c.Input)
+	}
+	if p := c.Panic; p == nil || p.Message != "boom" || filepath.Base(p.File) != "m_test.go" || p.Line != 14 {
+		t.Errorf("crash panic = %+v, want boom at m_test.go:14", p)
+	}
+}
+
+func decodeFuzzEvents(t *testing.T, buf *bytes.Buffer) []*FuzzEvent {
+	t.Helper()
+	var evs []*FuzzEvent
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var e FuzzEvent
+		if err := dec.Decode(&e); err != nil {
+			t.Fatal(err)
+		}
+		evs = append(evs, &e)
+	}
+	return evs
+}
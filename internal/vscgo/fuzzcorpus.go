@@ -0,0 +1,511 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	fuzzCorpusFlags = flag.NewFlagSet("fuzz-corpus", flag.ExitOnError)
+	fuzzCorpusCache = fuzzCorpusFlags.Bool("cache", false, "with list, also list the corpus generated by fuzzing, in GOCACHE")
+	fuzzCorpusFuzz  = fuzzCorpusFlags.String("fuzz", "", "with list, list only the corpus of this fuzz test")
+)
+
+// fuzzEncVersion1 is the first line of a corpus file in
+// the version 1 encoding.
+const fuzzEncVersion1 = "go test fuzz v1"
+
+// FuzzCorpus lists the corpus entries of the fuzz tests of a package.
+type FuzzCorpus struct {
+	Package  string `json:",omitempty"` // import path, if known
+	Dir      string
+	CacheDir string `json:",omitempty"` // directory of the generated corpus, with -cache
+	Entries  []*FuzzCorpusEntry
+}
+
+// FuzzCorpusEntry is a corpus file and its decoded values.
+type FuzzCorpusEntry struct {
+	Fuzz   string `json:",omitempty"` // name of the fuzz test
+	File   string `json:",omitempty"`
+	Cached bool   `json:",omitempty"` // generated by fuzzing, as opposed to in testdata
+	Values []*FuzzValue
+	Error  string `json:",omitempty"` // the file could not be decoded
+}
+
+// FuzzValue is a typed value of a corpus entry.
+//
+// Type is the type as written in the corpus file: []byte, string, bool,
+// byte, rune, float32, float64 or an integer type.
+//
+// Value is the value as text: strings and byte slices as is, numbers
+// in decimal (for floats, as strconv.FormatFloat with format 'g' and
+// including NaN, +Inf and -Inf), and bools as true or false. Strings
+// and byte slices that are not valid UTF-8 are instead given in Base64,
+// and NaNs other than math.NaN() are instead given as their bits in Bits.
+type FuzzValue struct {
+	Type   string
+	Value  string
+	Base64 string `json:",omitempty"`
+	Bits   string `json:",omitempty"` // hexadecimal, as 0x7fc00001
+}
+
+// decodeFuzzCorpus decodes a corpus file. It mirrors unmarshalCorpusFile
+// of the internal/fuzz package.
+func decodeFuzzCorpus(data []byte) ([]*FuzzValue, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("cannot unmarshal empty string")
+	}
+	lines := bytes.Split(data, []byte("\n"))
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("must include version and at least one value")
+	}
+	if version := strings.TrimSuffix(string(lines[0]), "\r"); version != fuzzEncVersion1 {
+		return nil, fmt.Errorf("unknown encoding version: %s", version)
+	}
+	vals := []*FuzzValue{}
+	for _, line := range lines[1:] {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		v, err := decodeFuzzValue(string(line))
+		if err != nil {
+			return nil, fmt.Errorf("malformed line %q: %v", line, err)
+		}
+		vals = append(vals, v)
+	}
+	return vals, nil
+}
+
+// decodeFuzzValue decodes a line of a corpus file, such as int(-3)
+// or []byte("\x00"). It mirrors parseCorpusValue of internal/fuzz.
+func decodeFuzzValue(line string) (*FuzzValue, error) {
+	expr, err := parser.ParseExpr(line)
+	if err != nil {
+		return nil, err
+	}
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return nil, fmt.Errorf("expected call expression")
+	}
+	if len(call.Args) != 1 {
+		return nil, fmt.Errorf("expected call expression with 1 argument; got %d", len(call.Args))
+	}
+	arg := call.Args[0]
+
+	var typ string
+	switch fun := call.Fun.(type) {
+	case *ast.ArrayType:
+		if fun.Len != nil || !isIdent(fun.Elt, "byte") {
+			return nil, fmt.Errorf("expected []byte")
+		}
+		typ = "[]byte"
+	case *ast.SelectorExpr:
+		switch {
+		case isIdent(fun.X, "math") && fun.Sel.Name == "Float32frombits":
+			typ = "float32-bits"
+		case isIdent(fun.X, "math") && fun.Sel.Name == "Float64frombits":
+			typ = "float64-bits"
+		default:
+			return nil, fmt.Errorf("invalid selector type")
+		}
+	case *ast.Ident:
+		typ = fun.Name
+	default:
+		return nil, fmt.Errorf("expected []byte or primitive type")
+	}
+
+	// The literal, with its sign.
+	var val string
+	var kind token.Token
+	switch arg := arg.(type) {
+	case *ast.BasicLit:
+		val, kind = arg.Value, arg.Kind
+	case *ast.Ident:
+		switch {
+		case typ == "bool" && (arg.Name == "true" || arg.Name == "false"):
+			return &FuzzValue{Type: typ, Value: arg.Name}, nil
+		case typ == "bool":
+			return nil, fmt.Errorf("true or false required for type bool")
+		case arg.Name != "NaN":
+			return nil, fmt.Errorf("literal value required for primitive type")
+		}
+		val, kind = "NaN", token.FLOAT
+	case *ast.UnaryExpr:
+		switch x := arg.X.(type) {
+		case *ast.BasicLit:
+			if arg.Op != token.SUB {
+				return nil, fmt.Errorf("unsupported operation on int/float: %v", arg.Op)
+			}
+			val, kind = "-"+x.Value, x.Kind
+		case *ast.Ident:
+			if x.Name != "Inf" || (arg.Op != token.SUB && arg.Op != token.ADD) {
+				return nil, fmt.Errorf("expected operation on int or float type")
+			}
+			val, kind = arg.Op.String()+"Inf", token.FLOAT
+		default:
+			return nil, fmt.Errorf("expected operation on int or float type")
+		}
+	default:
+		return nil, fmt.Errorf("literal value required for primitive type")
+	}
+
+	switch typ {
+	case "string", "[]byte":
+		if kind != token.STRING {
+			return nil, fmt.Errorf("string literal required for type %s", typ)
+		}
+		s, err := strconv.Unquote(val)
+		if err != nil {
+			return nil, err
+		}
+		return textFuzzValue(typ, s), nil
+	case "byte", "rune":
+		if kind == token.CHAR {
+			if len(val) < 2 {
+				return nil, fmt.Errorf("malformed character literal, missing single quotes")
+			}
+			r, _, _, err := strconv.UnquoteChar(val[1:len(val)-1], '\'')
+			if err != nil {
+				return nil, err
+			}
+			if typ == "byte" && r >= 256 {
+				return nil, fmt.Errorf("can only encode single byte to a byte type")
+			}
+			return &FuzzValue{Type: typ, Value: strconv.Itoa(int(r))}, nil
+		}
+		if kind != token.INT {
+			return nil, fmt.Errorf("character literal required for byte/rune types")
+		}
+		return intFuzzValue(typ, val)
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		if kind != token.INT {
+			return nil, fmt.Errorf("integer literal required for %s", typ)
+		}
+		return intFuzzValue(typ, val)
+	case "float32", "float64":
+		if kind != token.FLOAT && kind != token.INT {
+			return nil, fmt.Errorf("float or integer literal required for %s type", typ)
+		}
+		size := 64
+		if typ == "float32" {
+			size = 32
+		}
+		f, err := strconv.ParseFloat(val, size)
+		if err != nil {
+			return nil, err
+		}
+		return &FuzzValue{Type: typ, Value: strconv.FormatFloat(f, 'g', -1, size)}, nil
+	case "float32-bits", "float64-bits":
+		if kind != token.INT {
+			return nil, fmt.Errorf("integer literal required for math.%s", call.Fun.(*ast.SelectorExpr).Sel.Name)
+		}
+		size := 64
+		if typ == "float32-bits" {
+			size = 32
+		}
+		bits, err := strconv.ParseUint(val, 0, size)
+		if err != nil {
+			return nil, err
+		}
+		f := math.Float64frombits(bits)
+		if size == 32 {
+			f = float64(math.Float32frombits(uint32(bits)))
+		}
+		v := &FuzzValue{Type: typ[:len("float32")], Value: strconv.FormatFloat(f, 'g', -1, size)}
+		if math.IsNaN(f) {
+			v.Bits = fmt.Sprintf("%#x", bits)
+		}
+		return v, nil
+	}
+	return nil, fmt.Errorf("expected []byte or primitive type")
+}
+
+func textFuzzValue(typ, s string) *FuzzValue {
+	if utf8.ValidString(s) {
+		return &FuzzValue{Type: typ, Value: s}
+	}
+	return &FuzzValue{Type: typ, Base64: base64.StdEncoding.EncodeToString([]byte(s))}
+}
+
+// intFuzzValue returns the value of an integer literal of type typ.
+// Values of type int are 64 bits, whatever the architecture.
+func intFuzzValue(typ, lit string) (*FuzzValue, error) {
+	if strings.HasPrefix(typ, "u") || typ == "byte" {
+		n, err := strconv.ParseUint(lit, 0, fuzzIntSize(typ))
+		if err != nil {
+			return nil, err
+		}
+		return &FuzzValue{Type: typ, Value: strconv.FormatUint(n, 10)}, nil
+	}
+	n, err := strconv.ParseInt(lit, 0, fuzzIntSize(typ))
+	if err != nil {
+		return nil, err
+	}
+	return &FuzzValue{Type: typ, Value: strconv.FormatInt(n, 10)}, nil
+}
+
+func fuzzIntSize(typ string) int {
+	switch typ {
+	case "int8", "uint8", "byte":
+		return 8
+	case "int16", "uint16":
+		return 16
+	case "int32", "uint32", "rune":
+		return 32
+	}
+	return 64
+}
+
+// encodeFuzzCorpus encodes values as a corpus file. It mirrors
+// marshalCorpusFile of internal/fuzz, except that it writes uint8 and
+// int32 values as such, where that writes them as byte and rune.
+func encodeFuzzCorpus(vals []*FuzzValue) ([]byte, error) {
+	if len(vals) == 0 {
+		return nil, fmt.Errorf("must have at least one value to marshal")
+	}
+	var b bytes.Buffer
+	b.WriteString(fuzzEncVersion1 + "\n")
+	for i, v := range vals {
+		line, err := encodeFuzzValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("value %d: %v", i, err)
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.Bytes(), nil
+}
+
+func encodeFuzzValue(v *FuzzValue) (string, error) {
+	switch v.Type {
+	case "string", "[]byte":
+		s := v.Value
+		if v.Base64 != "" {
+			data, err := base64.StdEncoding.DecodeString(v.Base64)
+			if err != nil {
+				return "", err
+			}
+			s = string(data)
+		}
+		return fmt.Sprintf("%s(%q)", v.Type, s), nil
+	case "bool":
+		if v.Value != "true" && v.Value != "false" {
+			return "", fmt.Errorf("invalid bool %q", v.Value)
+		}
+		return "bool(" + v.Value + ")", nil
+	case "float32", "float64":
+		size := 64
+		if v.Type == "float32" {
+			size = 32
+		}
+		if v.Bits != "" {
+			bits, err := strconv.ParseUint(v.Bits, 0, size)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("math.Float%dfrombits(%#x)", size, bits), nil
+		}
+		f, err := strconv.ParseFloat(v.Value, size)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s(%s)", v.Type, strconv.FormatFloat(f, 'g', -1, size)), nil
+	case "byte", "rune":
+		fv, err := intFuzzValue(v.Type, v.Value)
+		if err != nil {
+			return "", err
+		}
+		n, _ := strconv.ParseInt(fv.Value, 10, 64)
+		if v.Type == "rune" && !utf8.ValidRune(rune(n)) {
+			// Runes that are not valid have no quoted form.
+			return fmt.Sprintf("int32(%d)", n), nil
+		}
+		return fmt.Sprintf("%s(%q)", v.Type, rune(n)), nil
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		fv, err := intFuzzValue(v.Type, v.Value)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s(%s)", v.Type, fv.Value), nil
+	}
+	return "", fmt.Errorf("unsupported type %q", v.Type)
+}
+
+// readFuzzCorpusEntry reads and decodes the corpus file.
+// Decoding errors are reported in the entry.
+func readFuzzCorpusEntry(file string) (*FuzzCorpusEntry, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	e := &FuzzCorpusEntry{File: file, Values: []*FuzzValue{}}
+	if vals, err := decodeFuzzCorpus(data); err != nil {
+		e.Error = err.Error()
+	} else {
+		e.Values = vals
+	}
+	return e, nil
+}
+
+// listFuzzCorpus lists the entries in the corpus directory dir, which
+// holds a directory of entries for each fuzz test, such as
+// testdata/fuzz. If fuzz is not empty, only its entries are listed.
+func listFuzzCorpus(dir, fuzz string, cached bool) ([]*FuzzCorpusEntry, error) {
+	targets, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var res []*FuzzCorpusEntry
+	for _, t := range targets {
+		if !t.IsDir() || fuzz != "" && t.Name() != fuzz {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(dir, t.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if f.IsDir() {
+				continue
+			}
+			e, err := readFuzzCorpusEntry(filepath.Join(dir, t.Name(), f.Name()))
+			if err != nil {
+				return nil, err
+			}
+			e.Fuzz, e.Cached = t.Name(), cached
+			res = append(res, e)
+		}
+	}
+	return res, nil
+}
+
+// fuzzCacheDir returns the directory of the corpus generated by fuzzing
+// the package with the given import path: $GOCACHE/fuzz/<import path>.
+func fuzzCacheDir(ctx context.Context, dir, pkg string) (string, error) {
+	cmd := exec.CommandContext(ctx, "go", "env", "GOCACHE")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("go env GOCACHE: %v", err)
+	}
+	gocache := strings.TrimSpace(string(out))
+	if gocache == "" || gocache == "off" {
+		return "", fmt.Errorf("the build cache is disabled")
+	}
+	return filepath.Join(gocache, "fuzz", filepath.FromSlash(pkg)), nil
+}
+
+// writeFuzzCorpusEntry writes data to dir, named by its hash as the
+// go command names new corpus entries, and returns its path.
+func writeFuzzCorpusEntry(dir string, data []byte) (string, error) {
+	name := fmt.Sprintf("%x", sha256.Sum256(data))[:16]
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return "", err
+	}
+	file := filepath.Join(dir, name)
+	if err := os.WriteFile(file, data, 0666); err != nil {
+		return "", err
+	}
+	return file, nil
+}
+
+func runFuzzCorpus(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: fuzz-corpus [flags] list|decode|encode ...")
+	}
+	enc := json.NewEncoder(os.Stdout)
+	switch cmd, args := args[0], args[1:]; cmd {
+	case "list":
+		if len(args) > 1 {
+			return fmt.Errorf("usage: fuzz-corpus [-cache] [-fuzz name] list [<package dir>]")
+		}
+		dir := "."
+		if len(args) == 1 {
+			dir = args[0]
+		}
+		dir, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		c := &FuzzCorpus{Dir: dir}
+		c.Entries, err = listFuzzCorpus(filepath.Join(dir, "testdata", "fuzz"), *fuzzCorpusFuzz, false)
+		if err != nil {
+			return err
+		}
+		if *fuzzCorpusCache {
+			ctx := context.Background()
+			if c.Package, err = goList(ctx, dir, "{{.ImportPath}}", "."); err != nil {
+				return err
+			}
+			if c.CacheDir, err = fuzzCacheDir(ctx, dir, c.Package); err != nil {
+				return err
+			}
+			cached, err := listFuzzCorpus(c.CacheDir, *fuzzCorpusFuzz, true)
+			if err != nil {
+				return err
+			}
+			c.Entries = append(c.Entries, cached...)
+		}
+		if c.Entries == nil {
+			c.Entries = []*FuzzCorpusEntry{}
+		}
+		return enc.Encode(c)
+
+	case "decode":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: fuzz-corpus decode <file>")
+		}
+		e, err := readFuzzCorpusEntry(args[0])
+		if err != nil {
+			return err
+		}
+		return enc.Encode(e)
+
+	case "encode":
+		// The entry is read from stdin, as decode prints it.
+		if len(args) > 1 {
+			return fmt.Errorf("usage: fuzz-corpus encode [<corpus dir>] < entry.json")
+		}
+		var e FuzzCorpusEntry
+		if err := json.NewDecoder(os.Stdin).Decode(&e); err != nil {
+			return fmt.Errorf("reading entry: %v", err)
+		}
+		data, err := encodeFuzzCorpus(e.Values)
+		if err != nil {
+			return err
+		}
+		if len(args) == 0 {
+			_, err := os.Stdout.Write(data)
+			return err
+		}
+		file, err := writeFuzzCorpusEntry(args[0], data)
+		if err != nil {
+			return err
+		}
+		return enc.Encode(&FuzzCorpusEntry{File: file, Values: e.Values})
+	}
+	return fmt.Errorf("unknown fuzz-corpus command %q", args[0])
+}
@@ -0,0 +1,134 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fuzzCorpusFile is a corpus file as the go command writes it.
+const fuzzCorpusFile = `go test fuzz v1
+[]byte("hello\x00")
+string("\xff")
+int(-3)
+int8(127)
+uint64(18446744073709551615)
+bool(true)
+byte('a')
+rune('☺')
+int32(-1)
+float32(1.5)
+float64(-Inf)
+float64(NaN)
+math.Float64frombits(0x7ff8000000000001)
+`
+
+func TestFuzzCorpusRoundTrip(t *testing.T) {
+	vals, err := decodeFuzzCorpus([]byte(fuzzCorpusFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*FuzzValue{
+		{Type: "[]byte", Value: "hello\x00"},
+		{Type: "string", Base64: "/w=="},
+		{Type: "int", Value: "-3"},
+		{Type: "int8", Value: "127"},
+		{Type: "uint64", Value: "18446744073709551615"},
+		{Type: "bool", Value: "true"},
+		{Type: "byte", Value: "97"},
+		{Type: "rune", Value: "9786"},
+		{Type: "int32", Value: "-1"},
+		{Type: "float32", Value: "1.5"},
+		{Type: "float64", Value: "-Inf"},
+		{Type: "float64", Value: "NaN"},
+		{Type: "float64", Value: "NaN", Bits: "0x7ff8000000000001"},
+	}
+	if !reflect.DeepEqual(vals, want) {
+		for i := range max(len(vals), len(want)) {
+			if i >= len(vals) || i >= len(want) || *vals[i] != *want[i] {
+				t.Errorf("value %d: got %+v, want %+v", i, vals[i:min(i+1, len(vals))], want[i:min(i+1, len(want))])
+			}
+		}
+	}
+
+	data, err := encodeFuzzCorpus(vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != fuzzCorpusFile {
+		t.Errorf("encoding the decoded values =\n%s\nwant\n%s", data, fuzzCorpusFile)
+	}
+
+	// The go command accepts any Go literal.
+	if v, err := decodeFuzzValue("int8(0x7f)"); err != nil || v.Value != "127" {
+		t.Errorf("decoding int8(0x7f) = %+v, %v, want 127", v, err)
+	}
+
+	for _, bad := range []string{
+		"",
+		"go test fuzz v2\nint(1)\n",
+		"go test fuzz v1\nint8(300)\n",
+		"go test fuzz v1\nbyte('☺')\n",
+		"go test fuzz v1\nstring(1)\n",
+		"go test fuzz v1\nfoo(1)\n",
+	} {
+		if _, err := decodeFuzzCorpus([]byte(bad)); err == nil {
+			t.Errorf("decoding %q succeeded", bad)
+		}
+	}
+	if _, err := encodeFuzzCorpus([]*FuzzValue{{Type: "uint8", Value: "-1"}}); err == nil {
+		t.Errorf("encoding uint8(-1) succeeded")
+	}
+}
+
+func TestListFuzzCorpus(t *testing.T) {
+	dir := writeFiles(t, t.TempDir(), map[string]string{
+		"testdata/fuzz/FuzzA/1": "go test fuzz v1\nint(1)\n",
+		"testdata/fuzz/FuzzA/2": "not a corpus file",
+		"testdata/fuzz/FuzzB/3": "go test fuzz v1\nstring(\"b\")\n",
+	})
+	corpus := filepath.Join(dir, "testdata", "fuzz")
+	entries, err := listFuzzCorpus(corpus, "FuzzA", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("entries = %+v, want 2", entries)
+	}
+	if e := entries[0]; e.Fuzz != "FuzzA" || len(e.Values) != 1 || e.Values[0].Value != "1" || e.Error != "" {
+		t.Errorf("entry 1 = %+v, want int(1)", e)
+	}
+	if e := entries[1]; e.Error == "" {
+		t.Errorf("entry 2 = %+v, want a decoding error", e)
+	}
+
+	data, err := encodeFuzzCorpus([]*FuzzValue{{Type: "string", Value: "new"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := writeFuzzCorpusEntry(filepath.Join(corpus, "FuzzB"), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := os.ReadFile(file); err != nil || string(got) != string(data) || len(filepath.Base(file)) != 16 {
+		t.Errorf("written entry %s = %q, %v, want %q", file, got, err, data)
+	}
+	if entries, _ := listFuzzCorpus(corpus, "FuzzB", false); len(entries) != 2 {
+		t.Errorf("FuzzB entries = %+v, want 2", entries)
+	}
+
+	t.Setenv("GOCACHE", filepath.Join(dir, "cache"))
+	cacheDir, err := fuzzCacheDir(context.Background(), dir, "example.com/m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "cache", "fuzz", "example.com", "m"); cacheDir != want {
+		t.Errorf("cache dir = %s, want %s", cacheDir, want)
+	}
+}
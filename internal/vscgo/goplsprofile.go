@@ -0,0 +1,253 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/pprof/profile"
+)
+
+var (
+	goplsProfileFlags    = flag.NewFlagSet("gopls-profile", flag.ExitOnError)
+	goplsProfileDuration = goplsProfileFlags.Duration("duration", 30*time.Second, "CPU profiling interval")
+	goplsProfileKinds    = goplsProfileFlags.String("kinds", "cpu,heap,goroutine", "comma-separated profiles to capture: cpu, heap, goroutine, allocs, block, mutex")
+	goplsProfileOut      = goplsProfileFlags.String("out", "", "directory to write the profiles and their metadata to (default: a new temporary directory)")
+	goplsProfileStore    = goplsProfileFlags.Bool("store", false, "also add the profiles to the profile store")
+	goplsProfileDir      = goplsProfileFlags.String("dir", "", "profile store directory used with -store (default: vscode-go/profiles in the user cache directory)")
+	goplsProfileServe    = goplsProfileFlags.String("serve", "", "if set, serve the CPU profile (or the first captured profile) on this address after capturing, as serve-pprof does")
+)
+
+// GoplsProfiles describes the profiles captured from a gopls debug server.
+type GoplsProfiles struct {
+	Addr         string
+	GoplsVersion string `json:",omitempty"`
+	GoVersion    string `json:",omitempty"`
+	Cmdline      []string
+	Start        time.Time
+	Duration     time.Duration // CPU profiling interval
+	Profiles     []*GoplsProfile
+}
+
+// GoplsProfile is one profile captured from gopls.
+type GoplsProfile struct {
+	Kind    string
+	File    string
+	StoreID string `json:",omitempty"` // ID in the profile store, with -store
+}
+
+// goplsDebugClient fetches data from a gopls debug server,
+// started with gopls -debug=<addr>.
+type goplsDebugClient struct {
+	base   string // URL of the server, without a trailing slash
+	client *http.Client
+}
+
+func newGoplsDebugClient(addr string) *goplsDebugClient {
+	base := strings.TrimSuffix(addr, "/")
+	if !strings.Contains(base, "://") {
+		if strings.HasPrefix(base, ":") {
+			base = "localhost" + base
+		}
+		base = "http://" + base
+	}
+	return &goplsDebugClient{base: base, client: http.DefaultClient}
+}
+
+func (c *goplsDebugClient) get(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.base+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s: %s", c.base+path, resp.Status, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
+
+var (
+	goplsVersionRx = regexp.MustCompile(`(?m)^\s*golang\.org/x/tools/gopls\s+(\S+)`)
+	goVersionRx    = regexp.MustCompile(`(?m)^\s*go:\s*(go\S+)`)
+	htmlTagRx      = regexp.MustCompile(`<[^>]*>`)
+)
+
+// versions returns the gopls and Go versions reported by the
+// server's /info page, or empty strings if they are unknown.
+func (c *goplsDebugClient) versions(ctx context.Context) (gopls, goVersion string, _ error) {
+	data, err := c.get(ctx, "/info")
+	if err != nil {
+		return "", "", err
+	}
+	text := html.UnescapeString(htmlTagRx.ReplaceAllString(string(data), "\n"))
+	if m := goplsVersionRx.FindStringSubmatch(text); m != nil {
+		gopls = m[1]
+	}
+	if m := goVersionRx.FindStringSubmatch(text); m != nil {
+		goVersion = m[1]
+	}
+	return gopls, goVersion, nil
+}
+
+// profile fetches a profile of the given kind. CPU profiles
+// are collected over the given duration.
+func (c *goplsDebugClient) profile(ctx context.Context, kind string, d time.Duration) ([]byte, error) {
+	path := "/debug/pprof/" + kind
+	if kind == "cpu" {
+		path = fmt.Sprintf("/debug/pprof/profile?seconds=%d", max(1, int(d.Round(time.Second)/time.Second)))
+	}
+	data, err := c.get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := profile.ParseData(data); err != nil {
+		return nil, fmt.Errorf("%s profile: %v", kind, err)
+	}
+	return data, nil
+}
+
+// captureGoplsProfiles captures the profiles of the given kinds from the
+// gopls debug server at addr, and writes them to dir. The CPU profile is
+// collected first, and the others at the end of its interval.
+func captureGoplsProfiles(ctx context.Context, addr string, kinds []string, d time.Duration, dir string) (*GoplsProfiles, error) {
+	c := newGoplsDebugClient(addr)
+	res := &GoplsProfiles{
+		Addr:     c.base,
+		Cmdline:  []string{},
+		Start:    time.Now().UTC(),
+		Duration: d,
+		Profiles: []*GoplsProfile{},
+	}
+	var err error
+	res.GoplsVersion, res.GoVersion, err = c.versions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to gopls debug server: %v", err)
+	}
+	if data, err := c.get(ctx, "/debug/pprof/cmdline"); err == nil {
+		res.Cmdline = strings.Split(strings.TrimRight(string(data), "\x00"), "\x00")
+	}
+
+	// Capture the CPU profile first, so the others reflect
+	// the state at the end of the interval.
+	ordered := make([]string, 0, len(kinds))
+	for _, k := range kinds {
+		if k == "cpu" {
+			ordered = append([]string{k}, ordered...)
+		} else {
+			ordered = append(ordered, k)
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	for _, k := range ordered {
+		data, err := c.profile(ctx, k, d)
+		if err != nil {
+			return nil, err
+		}
+		file := filepath.Join(dir, "gopls-"+k+".pb.gz")
+		if err := os.WriteFile(file, data, 0644); err != nil {
+			return nil, err
+		}
+		res.Profiles = append(res.Profiles, &GoplsProfile{Kind: k, File: file})
+	}
+
+	js, err := json.MarshalIndent(res, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "metadata.json"), js, 0644); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func runGoplsProfile(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: gopls-profile [flags] <gopls debug address>")
+	}
+	kinds := splitKeys(*goplsProfileKinds)
+	if len(kinds) == 0 {
+		return fmt.Errorf("no profiles to capture")
+	}
+	for _, k := range kinds {
+		switch k {
+		case "cpu", "heap", "goroutine", "allocs", "block", "mutex":
+		default:
+			return fmt.Errorf("unknown profile kind %q", k)
+		}
+	}
+	dir := *goplsProfileOut
+	if dir == "" {
+		d, err := os.MkdirTemp("", "gopls-profiles-")
+		if err != nil {
+			return err
+		}
+		dir = d
+	}
+
+	ctx := context.Background()
+	res, err := captureGoplsProfiles(ctx, args[0], kinds, *goplsProfileDuration, dir)
+	if err != nil {
+		return err
+	}
+
+	if *goplsProfileStore {
+		s, err := openProfileStore(*goplsProfileDir)
+		if err != nil {
+			return err
+		}
+		for _, gp := range res.Profiles {
+			data, err := os.ReadFile(gp.File)
+			if err != nil {
+				return err
+			}
+			sp, err := s.add(data, StoredProfile{
+				Kind:    gp.Kind,
+				Package: "golang.org/x/tools/gopls",
+				Extra: map[string]string{
+					"gopls": res.GoplsVersion,
+					"go":    res.GoVersion,
+					"addr":  res.Addr,
+				},
+			}, time.Now())
+			if err != nil {
+				return err
+			}
+			gp.StoreID = sp.ID
+		}
+	}
+
+	if err := json.NewEncoder(os.Stdout).Encode(res); err != nil {
+		return err
+	}
+	if *goplsProfileServe == "" {
+		return nil
+	}
+	p, err := readPprof(res.Profiles[0].File)
+	if err != nil {
+		return err
+	}
+	return servePprof(*goplsProfileServe, p)
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCaptureGoplsProfiles(t *testing.T) {
+	data := profileBytes(t, testProfile())
+	var gotSeconds string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/info", func(w http.ResponseWriter, r *http.Request) {
+		// Mimic the layout of gopls's /info page.
+		w.Write([]byte(`<html><body><h2>Build info</h2>
+golang.org/x/tools/gopls v0.20.0
+    golang.org/x/tools/gopls@v0.20.0 h1:abc=
+    golang.org/x/mod@v0.28.0 h1:def=
+go: go1.26.1
+</body></html>`))
+	})
+	mux.HandleFunc("/debug/pprof/cmdline", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("gopls\x00-debug=localhost:0\x00"))
+	})
+	mux.HandleFunc("/debug/pprof/profile", func(w http.ResponseWriter, r *http.Request) {
+		gotSeconds = r.URL.Query().Get("seconds")
+		w.Write(data)
+	})
+	mux.HandleFunc("/debug/pprof/heap", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(data)
+	})
+	mux.HandleFunc("/debug/pprof/goroutine", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("not a profile"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	addr := strings.TrimPrefix(srv.URL, "http://")
+	res, err := captureGoplsProfiles(context.Background(), addr, []string{"heap", "cpu"}, 2*time.Second, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.GoplsVersion != "v0.20.0" || res.GoVersion != "go1.26.1" {
+		t.Errorf("versions = %q, %q, want v0.20.0, go1.26.1", res.GoplsVersion, res.GoVersion)
+	}
+	if want := []string{"gopls", "-debug=localhost:0"}; !reflect.DeepEqual(res.Cmdline, want) {
+		t.Errorf("Cmdline = %q, want %q", res.Cmdline, want)
+	}
+	if gotSeconds != "2" {
+		t.Errorf("CPU profile requested for %q seconds, want 2", gotSeconds)
+	}
+	var kinds []string
+	for _, p := range res.Profiles {
+		kinds = append(kinds, p.Kind)
+		if _, err := readPprof(p.File); err != nil {
+			t.Errorf("reading %s profile: %v", p.Kind, err)
+		}
+	}
+	if want := []string{"cpu", "heap"}; !reflect.DeepEqual(kinds, want) {
+		t.Errorf("captured %v, want %v", kinds, want)
+	}
+	js, err := os.ReadFile(filepath.Join(dir, "metadata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var meta GoplsProfiles
+	if err := json.Unmarshal(js, &meta); err != nil || meta.GoplsVersion != "v0.20.0" {
+		t.Errorf("metadata.json = %s (%v), want gopls version v0.20.0", js, err)
+	}
+
+	if _, err := captureGoplsProfiles(context.Background(), srv.URL, []string{"goroutine"}, time.Second, dir); err == nil {
+		t.Errorf("capturing an invalid goroutine profile succeeded")
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"debug/buildinfo"
+	"debug/dwarf"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"slices"
+	"strings"
+)
+
+// BinaryInfo describes a Go executable.
+type BinaryInfo struct {
+	File      string
+	Format    string // elf, macho or pe
+	GOOS      string `json:",omitempty"`
+	GOARCH    string `json:",omitempty"`
+	GoVersion string
+	Path      string        `json:",omitempty"` // the main package path
+	Main      *debug.Module `json:",omitempty"`
+	Deps      []*debug.Module
+	Settings  []debug.BuildSetting
+	VCS       *BinaryVCS `json:",omitempty"`
+	// Flags are the build flags recorded in the build settings,
+	// such as -ldflags and -tags.
+	Flags map[string]string
+	// Stripped reports whether the symbol table was removed, as with
+	// -ldflags=-s.
+	Stripped bool
+	// DWARF reports whether the executable has debug information:
+	// it does not with -ldflags=-w.
+	DWARF bool
+	// Optimized and Inlined report whether the packages of the main
+	// module were compiled with optimizations and inlining, that is
+	// without -gcflags=-N and -l. They are derived from the debug
+	// information if any, and from -gcflags otherwise.
+	Optimized bool
+	Inlined   bool
+	Trimpath  bool // whether file paths were trimmed, with -trimpath
+}
+
+// BinaryVCS is the version control information of the main module.
+type BinaryVCS struct {
+	System   string
+	Revision string `json:",omitempty"`
+	Time     string `json:",omitempty"`
+	Modified bool
+}
+
+// inspectBinary returns the description of the Go executable file.
+func inspectBinary(file string) (*BinaryInfo, error) {
+	bi, err := buildinfo.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	info := &BinaryInfo{
+		File:      file,
+		GoVersion: bi.GoVersion,
+		Path:      bi.Path,
+		Deps:      bi.Deps,
+		Settings:  bi.Settings,
+		Flags:     map[string]string{},
+		Optimized: true,
+		Inlined:   true,
+	}
+	if info.Deps == nil {
+		info.Deps = []*debug.Module{}
+	}
+	if bi.Main.Path != "" {
+		info.Main = &bi.Main
+	}
+	for _, s := range bi.Settings {
+		switch {
+		case s.Key == "GOOS":
+			info.GOOS = s.Value
+		case s.Key == "GOARCH":
+			info.GOARCH = s.Value
+		case s.Key == "-trimpath":
+			info.Trimpath = s.Value == "true"
+		case strings.HasPrefix(s.Key, "-"):
+			info.Flags[s.Key] = s.Value
+		case strings.HasPrefix(s.Key, "vcs"):
+			if info.VCS == nil {
+				info.VCS = &BinaryVCS{}
+			}
+			switch s.Key {
+			case "vcs":
+				info.VCS.System = s.Value
+			case "vcs.revision":
+				info.VCS.Revision = s.Value
+			case "vcs.time":
+				info.VCS.Time = s.Value
+			case "vcs.modified":
+				info.VCS.Modified = s.Value == "true"
+			}
+		}
+	}
+
+	var d *dwarf.Data
+	if f, err := elf.Open(file); err == nil {
+		defer f.Close()
+		info.Format = "elf"
+		info.Stripped = f.Section(".symtab") == nil
+		d, _ = f.DWARF()
+	} else if f, err := macho.Open(file); err == nil {
+		defer f.Close()
+		info.Format = "macho"
+		info.Stripped = f.Symtab == nil || len(f.Symtab.Syms) == 0
+		d, _ = f.DWARF()
+	} else if f, err := pe.Open(file); err == nil {
+		defer f.Close()
+		info.Format = "pe"
+		info.Stripped = len(f.Symbols) == 0
+		d, _ = f.DWARF()
+	} else {
+		// buildinfo also reads XCOFF and Plan 9 executables.
+		info.Format = "unknown"
+	}
+	info.DWARF = d != nil
+
+	if d == nil || !compileFlags(info, d, bi.Main.Path) {
+		for _, f := range strings.Fields(info.Flags["-gcflags"]) {
+			// Flags may apply to a pattern, as all=-N.
+			if _, v, ok := strings.Cut(f, "="); ok {
+				f = v
+			}
+			info.Optimized = info.Optimized && f != "-N"
+			info.Inlined = info.Inlined && f != "-l"
+		}
+	}
+	return info, nil
+}
+
+// compileFlags sets info.Optimized and info.Inlined from the producers
+// of the compilation units of the main module's packages, which end
+// with the compiler's flags, as in "Go cmd/compile go1.22.0; -N -l".
+// It reports whether it found such a compilation unit.
+func compileFlags(info *BinaryInfo, d *dwarf.Data, mod string) bool {
+	found := false
+	optimized, inlined := false, false
+	r := d.Reader()
+	for {
+		e, err := r.Next()
+		if err != nil || e == nil {
+			break
+		}
+		r.SkipChildren()
+		if e.Tag != dwarf.TagCompileUnit {
+			continue
+		}
+		name, _ := e.Val(dwarf.AttrName).(string)
+		producer, _ := e.Val(dwarf.AttrProducer).(string)
+		if name != "main" && (mod == "" || name != mod && !strings.HasPrefix(name, mod+"/")) {
+			continue
+		}
+		_, flags, _ := strings.Cut(producer, ";")
+		fields := strings.Fields(flags)
+		found = true
+		optimized = optimized || !slices.Contains(fields, "-N")
+		inlined = inlined || !slices.Contains(fields, "-l")
+	}
+	if found {
+		info.Optimized, info.Inlined = optimized, inlined
+	}
+	return found
+}
+
+func runInspectBinary(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: inspect-binary <executable>")
+	}
+	info, err := inspectBinary(args[0])
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(os.Stdout).Encode(info)
+}
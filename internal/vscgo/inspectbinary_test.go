@@ -0,0 +1,67 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestInspectBinary(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds executables")
+	}
+	dir := writeFiles(t, t.TempDir(), map[string]string{
+		"go.mod":      "module example.com/m\n\ngo 1.21\n",
+		"cmd/main.go": "package main\n\nfunc main() {}\n",
+		"notgo":       "#!/bin/sh\n",
+	})
+	build := func(name string, flags ...string) string {
+		t.Helper()
+		exe := filepath.Join(dir, name)
+		cmd := exec.Command("go", append(append([]string{"build", "-o", exe}, flags...), "./cmd")...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("go build %s: %v\n%s", flags, err, out)
+		}
+		return exe
+	}
+
+	info, err := inspectBinary(build("default"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.GoVersion != runtime.Version() || info.GOOS != runtime.GOOS || info.GOARCH != runtime.GOARCH {
+		t.Errorf("built by %s for %s/%s, want %s for %s/%s", info.GoVersion, info.GOOS, info.GOARCH, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	}
+	if info.Path != "example.com/m/cmd" || info.Main == nil || info.Main.Path != "example.com/m" {
+		t.Errorf("main package %s in %+v, want example.com/m/cmd", info.Path, info.Main)
+	}
+	if info.Stripped || !info.DWARF || !info.Optimized || !info.Inlined || info.Trimpath {
+		t.Errorf("default build = %+v, want debug information and optimizations", info)
+	}
+
+	info, err = inspectBinary(build("debug", "-gcflags=all=-N -l", "-trimpath"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.DWARF || info.Optimized || info.Inlined || !info.Trimpath || info.Flags["-gcflags"] != "all=-N -l" {
+		t.Errorf("debug build = %+v, want no optimizations and trimmed paths", info)
+	}
+
+	info, err = inspectBinary(build("stripped", "-ldflags=-s -w", "-gcflags=-N"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Stripped || info.DWARF || info.Optimized || !info.Inlined {
+		t.Errorf("stripped build = %+v, want no symbols, no debug information and no optimizations", info)
+	}
+
+	if _, err := inspectBinary(filepath.Join(dir, "notgo")); err == nil {
+		t.Errorf("inspecting a script succeeded")
+	}
+}
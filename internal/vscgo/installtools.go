@@ -0,0 +1,323 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/version"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	installToolsFlags   = flag.NewFlagSet("install-tools", flag.ExitOnError)
+	installToolsGOBIN   = installToolsFlags.String("gobin", "", "directory to install the tools in (default: GOBIN, or the bin directory of the first GOPATH entry)")
+	installToolsP       = installToolsFlags.Int("p", 4, "number of tools to install in parallel")
+	installToolsRetries = installToolsFlags.Int("retries", 2, "number of times to retry an installation that failed with a transient error")
+)
+
+// ToolInstallEvent is an event of the installation of a tool.
+// Kind is one of:
+//
+//	start     - the installation of the tool starts
+//	resolve   - the version of the tool, and the Go version it requires,
+//	            are resolved; Toolchain is the GOTOOLCHAIN to install it with
+//	retry     - an attempt failed with a transient error, in Error and
+//	            Output, and the installation is retried
+//	installed - the tool was installed as Binary
+//	fail      - the installation failed, with Error and Output
+type ToolInstallEvent struct {
+	Kind       string
+	Tool       string
+	ImportPath string
+	Version    string  `json:",omitempty"`
+	Module     string  `json:",omitempty"`
+	GoVersion  string  `json:",omitempty"` // the go line of the tool's module
+	Toolchain  string  `json:",omitempty"`
+	Attempt    int     `json:",omitempty"`
+	Binary     string  `json:",omitempty"`
+	Error      string  `json:",omitempty"`
+	Output     string  `json:",omitempty"` // output of the go command
+	Elapsed    float64 `json:",omitempty"` // seconds since the installation started
+}
+
+// toolSpec is a tool to install, given as [name=]importpath[@version].
+type toolSpec struct {
+	name       string // the name of the binary
+	importPath string
+	version    string
+}
+
+// parseToolSpec parses a tool to install. The version defaults to
+// latest, and the name to that go install gives the binary: the last
+// element of the import path that is not a major version suffix.
+func parseToolSpec(s string) (toolSpec, error) {
+	var t toolSpec
+	name, spec, ok := strings.Cut(s, "=")
+	if !ok {
+		name, spec = "", s
+	}
+	t.importPath, t.version, _ = strings.Cut(spec, "@")
+	if t.version == "" {
+		t.version = "latest"
+	}
+	if t.importPath == "" || strings.HasPrefix(t.importPath, "/") || strings.HasPrefix(t.importPath, ".") {
+		return t, fmt.Errorf("invalid tool %q: want [name=]importpath[@version]", s)
+	}
+	t.name = name
+	if t.name == "" {
+		elem := path.Base(t.importPath)
+		if majorVersionRx.MatchString(elem) && path.Dir(t.importPath) != "." {
+			elem = path.Base(path.Dir(t.importPath))
+		}
+		t.name = elem
+	}
+	return t, nil
+}
+
+var (
+	majorVersionRx = regexp.MustCompile(`^v[2-9][0-9]*$|^v[1-9][0-9]+$`)
+	// transientErrorRx matches errors of the go command that are worth
+	// retrying: network and proxy errors.
+	transientErrorRx = regexp.MustCompile(`(?i)\b(50[0234]|429)\b|connection (reset|refused)|i/o timeout|TLS handshake timeout|timeout awaiting|unexpected EOF|temporary failure|no such host`)
+)
+
+// toolchainFor returns the GOTOOLCHAIN to install a tool whose module
+// requires Go version goVersion, as in its go line, with the local
+// toolchain local, such as go1.22.1: local if it is recent enough, and
+// otherwise the first release of goVersion.
+func toolchainFor(local, goVersion string) string {
+	if goVersion == "" || !version.IsValid(local) || version.Compare("go"+goVersion, local) <= 0 {
+		return "local"
+	}
+	v := "go" + goVersion
+	if version.Lang(v) == v && version.Compare(v, "go1.21") >= 0 {
+		// Since Go 1.21, go 1.N means go 1.N.0.
+		v += ".0"
+	}
+	return v
+}
+
+// toolInstaller installs tools with go install.
+type toolInstaller struct {
+	gobin   string
+	local   string // the version of the local toolchain
+	retries int
+	backoff time.Duration // the delay before the first retry, doubled for each one
+
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func (ti *toolInstaller) emit(e *ToolInstallEvent) {
+	ti.mu.Lock()
+	defer ti.mu.Unlock()
+	ti.enc.Encode(e)
+}
+
+// goCommand runs the go command with args in dir, with the additional
+// environment env, and returns its combined output.
+func goCommand(ctx context.Context, dir string, env []string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	return cmd.CombinedOutput()
+}
+
+// resolve returns the module providing t's package at t's version, with
+// its version and the Go version it requires. The module is the longest
+// prefix of the import path that is a module at that version.
+func (ti *toolInstaller) resolve(ctx context.Context, dir string, t toolSpec) (mod, vers, goVersion string, _ error) {
+	var firstErr error
+	for p := t.importPath; p != "." && p != "/"; p = path.Dir(p) {
+		out, err := goCommand(ctx, dir, []string{"GOTOOLCHAIN=local", "GO111MODULE=on"},
+			"list", "-m", "-json", p+"@"+t.version)
+		if err != nil {
+			if transientErrorRx.Match(out) {
+				// Do not mistake a transient error for a
+				// missing module.
+				return "", "", "", fmt.Errorf("%s", bytes.TrimSpace(out))
+			}
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s", bytes.TrimSpace(out))
+			}
+			continue
+		}
+		var m struct{ Path, Version, GoVersion string }
+		if err := json.Unmarshal(out, &m); err != nil {
+			return "", "", "", fmt.Errorf("reading go list output: %v", err)
+		}
+		return m.Path, m.Version, m.GoVersion, nil
+	}
+	return "", "", "", firstErr
+}
+
+// install installs t, retrying transient failures, and reports whether
+// it succeeded.
+func (ti *toolInstaller) install(ctx context.Context, t toolSpec) bool {
+	start := time.Now()
+	ev := func(kind string) *ToolInstallEvent {
+		return &ToolInstallEvent{Kind: kind, Tool: t.name, ImportPath: t.importPath, Elapsed: time.Since(start).Seconds()}
+	}
+	fail := func(err error, out []byte) bool {
+		e := ev("fail")
+		e.Error, e.Output = err.Error(), string(out)
+		ti.emit(e)
+		return false
+	}
+	e := ev("start")
+	e.Version = t.version
+	ti.emit(e)
+
+	// The go command runs in a temporary directory outside of any
+	// module, and installs the tool there, on the same file system as
+	// GOBIN, before moving it into GOBIN.
+	tmp, err := os.MkdirTemp(ti.gobin, ".install-"+t.name+"-")
+	if err != nil {
+		return fail(err, nil)
+	}
+	defer os.RemoveAll(tmp)
+
+	var mod, vers, goVersion string
+	var out []byte
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return fail(ctx.Err(), out)
+			case <-time.After(ti.backoff << (attempt - 1)):
+			}
+		}
+		err, out = nil, nil
+		if mod == "" {
+			mod, vers, goVersion, err = ti.resolve(ctx, tmp, t)
+			if err == nil {
+				e := ev("resolve")
+				e.Module, e.Version, e.GoVersion, e.Toolchain = mod, vers, goVersion, toolchainFor(ti.local, goVersion)
+				ti.emit(e)
+			}
+		}
+		if err == nil {
+			bin := filepath.Join(tmp, "bin")
+			env := []string{"GOBIN=" + bin, "GOTOOLCHAIN=" + toolchainFor(ti.local, goVersion), "GO111MODULE=on"}
+			out, err = goCommand(ctx, tmp, env, "install", t.importPath+"@"+vers)
+			if err == nil {
+				break
+			}
+			err = fmt.Errorf("go install %s@%s: %v", t.importPath, vers, err)
+		}
+		if ctx.Err() != nil || attempt >= ti.retries || !transientErrorRx.MatchString(err.Error()+"\n"+string(out)) {
+			return fail(err, out)
+		}
+		e := ev("retry")
+		e.Attempt, e.Error, e.Output = attempt+1, err.Error(), string(out)
+		ti.emit(e)
+	}
+
+	// go install names the binary after the package path, and adds
+	// the executable suffix of GOOS.
+	entries, err := os.ReadDir(filepath.Join(tmp, "bin"))
+	if err != nil || len(entries) != 1 {
+		return fail(fmt.Errorf("go install did not install a single binary: %v", err), out)
+	}
+	built := entries[0].Name()
+	dst := filepath.Join(ti.gobin, t.name+filepath.Ext(built))
+	if err := os.Rename(filepath.Join(tmp, "bin", built), dst); err != nil {
+		return fail(err, out)
+	}
+	e = ev("installed")
+	e.Module, e.Version, e.GoVersion, e.Toolchain, e.Binary = mod, vers, goVersion, toolchainFor(ti.local, goVersion), dst
+	ti.emit(e)
+	return true
+}
+
+// installTools installs the tools, parallel at a time, and returns the
+// names of those that failed.
+func (ti *toolInstaller) installTools(ctx context.Context, tools []toolSpec, parallel int) []string {
+	var (
+		mu     sync.Mutex
+		failed []string
+		wg     sync.WaitGroup
+	)
+	sem := make(chan struct{}, max(parallel, 1))
+	for _, t := range tools {
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if !ti.install(ctx, t) {
+				mu.Lock()
+				failed = append(failed, t.name)
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	return failed
+}
+
+// newToolInstaller returns an installer into gobin, which defaults to
+// that of go install, that writes its events to w.
+func newToolInstaller(ctx context.Context, gobin string, w io.Writer) (*toolInstaller, error) {
+	out, err := goCommand(ctx, "", []string{"GOTOOLCHAIN=local"}, "env", "GOVERSION", "GOBIN", "GOPATH")
+	if err != nil {
+		return nil, fmt.Errorf("go env: %v: %s", err, out)
+	}
+	lines := strings.Split(string(out), "\n")
+	if len(lines) < 3 {
+		return nil, fmt.Errorf("unexpected go env output: %s", out)
+	}
+	if gobin == "" {
+		gobin = lines[1]
+	}
+	if gobin == "" {
+		gopath, _, _ := strings.Cut(lines[2], string(filepath.ListSeparator))
+		if gopath == "" {
+			return nil, errors.New("neither GOBIN nor GOPATH is set")
+		}
+		gobin = filepath.Join(gopath, "bin")
+	}
+	if err := os.MkdirAll(gobin, 0777); err != nil {
+		return nil, err
+	}
+	return &toolInstaller{gobin: gobin, local: lines[0], backoff: time.Second, enc: json.NewEncoder(w)}, nil
+}
+
+func runInstallTools(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: install-tools [flags] [name=]importpath[@version]...")
+	}
+	var tools []toolSpec
+	for _, a := range args {
+		t, err := parseToolSpec(a)
+		if err != nil {
+			return err
+		}
+		tools = append(tools, t)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ti, err := newToolInstaller(ctx, *installToolsGOBIN, os.Stdout)
+	if err != nil {
+		return err
+	}
+	ti.retries = *installToolsRetries
+	if failed := ti.installTools(ctx, tools, *installToolsP); len(failed) > 0 {
+		return fmt.Errorf("%d of %d tools failed to install: %s", len(failed), len(tools), strings.Join(failed, " "))
+	}
+	return nil
+}
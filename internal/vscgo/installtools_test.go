@@ -0,0 +1,199 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/mod/module"
+)
+
+// writeModuleProxy writes a module proxy, in the layout GOPROXY=file://
+// serves, to dir. The modules are the files of each module version, by
+// module path and version.
+func writeModuleProxy(t *testing.T, dir string, modules map[string]map[string]string) string {
+	t.Helper()
+	files := map[string]string{}
+	versions := map[string][]string{}
+	for mv, content := range modules {
+		mod, vers, _ := strings.Cut(mv, "@")
+		escaped, err := module.EscapePath(mod)
+		if err != nil {
+			t.Fatal(err)
+		}
+		versions[escaped] = append(versions[escaped], vers)
+		base := escaped + "/@v/" + vers
+		files[base+".info"] = fmt.Sprintf(`{"Version":%q,"Time":"2026-01-02T15:04:05Z"}`, vers)
+		files[base+".mod"] = content["go.mod"]
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		for _, name := range slices.Sorted(maps.Keys(content)) {
+			w, err := zw.Create(mv + "/" + name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Write([]byte(content[name]))
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		files[base+".zip"] = buf.String()
+	}
+	for mod, vs := range versions {
+		files[mod+"/@v/list"] = strings.Join(vs, "\n") + "\n"
+	}
+	return writeFiles(t, dir, files)
+}
+
+func TestParseToolSpec(t *testing.T) {
+	for _, test := range []struct {
+		spec                   string
+		name, importPath, vers string
+		wantErr                bool
+	}{
+		{spec: "golang.org/x/tools/gopls@v0.20.0", name: "gopls", importPath: "golang.org/x/tools/gopls", vers: "v0.20.0"},
+		{spec: "github.com/go-delve/delve/cmd/dlv", name: "dlv", importPath: "github.com/go-delve/delve/cmd/dlv", vers: "latest"},
+		{spec: "example.com/tool/v2", name: "tool", importPath: "example.com/tool/v2", vers: "latest"},
+		{spec: "lint=honnef.co/go/tools/cmd/staticcheck@2025.1", name: "lint", importPath: "honnef.co/go/tools/cmd/staticcheck", vers: "2025.1"},
+		{spec: "./cmd/tool", wantErr: true},
+		{spec: "@latest", wantErr: true},
+	} {
+		ts, err := parseToolSpec(test.spec)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("parseToolSpec(%q) succeeded, want an error", test.spec)
+			}
+			continue
+		}
+		if err != nil || ts.name != test.name || ts.importPath != test.importPath || ts.version != test.vers {
+			t.Errorf("parseToolSpec(%q) = %+v, %v, want %s %s %s", test.spec, ts, err, test.name, test.importPath, test.vers)
+		}
+	}
+}
+
+func TestToolchainFor(t *testing.T) {
+	for _, test := range []struct{ local, goVersion, want string }{
+		{"go1.22.1", "", "local"},
+		{"go1.22.1", "1.18", "local"},
+		{"go1.22.1", "1.22", "local"},
+		{"go1.22.1", "1.22.1", "local"},
+		{"go1.22.1", "1.22.4", "go1.22.4"},
+		{"go1.22.1", "1.23", "go1.23.0"},
+		{"go1.22.1", "1.24rc1", "go1.24rc1"},
+		{"devel go1.27-abcdef", "1.30", "local"},
+	} {
+		if got := toolchainFor(test.local, test.goVersion); got != test.want {
+			t.Errorf("toolchainFor(%q, %q) = %q, want %q", test.local, test.goVersion, got, test.want)
+		}
+	}
+}
+
+func TestInstallTools(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds tools")
+	}
+	proxy := writeModuleProxy(t, t.TempDir(), map[string]map[string]string{
+		"example.com/tool@v1.0.0": {
+			"go.mod":            "module example.com/tool\n\ngo 1.21\n",
+			"cmd/hello/main.go": "package main\n\nfunc main() { println(\"hello\") }\n",
+			"cmd/other/main.go": "package main\n\nfunc main() {}\n",
+		},
+	})
+	// The proxy fails the first download of the module zip.
+	var zips atomic.Int32
+	files := http.FileServer(http.Dir(proxy))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, ".zip") && zips.Add(1) == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		files.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	t.Setenv("GOPROXY", srv.URL)
+	t.Setenv("GOSUMDB", "off")
+	t.Setenv("GOFLAGS", "-modcacherw")
+	t.Setenv("GOMODCACHE", t.TempDir())
+
+	var out bytes.Buffer
+	gobin := filepath.Join(t.TempDir(), "bin")
+	ti, err := newToolInstaller(context.Background(), gobin, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ti.retries, ti.backoff = 2, time.Millisecond
+	var tools []toolSpec
+	for _, spec := range []string{"example.com/tool/cmd/hello@v1.0.0", "renamed=example.com/tool/cmd/other", "example.com/missing/cmd/x"} {
+		ts, err := parseToolSpec(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tools = append(tools, ts)
+	}
+	failed := ti.installTools(context.Background(), tools, 2)
+	if !slices.Equal(failed, []string{"x"}) {
+		t.Errorf("failed tools = %v, want [x]", failed)
+	}
+
+	kinds := map[string][]string{}
+	dec := json.NewDecoder(&out)
+	for {
+		var e ToolInstallEvent
+		if err := dec.Decode(&e); err != nil {
+			break
+		}
+		kinds[e.Tool] = append(kinds[e.Tool], e.Kind)
+		switch e.Kind {
+		case "installed":
+			if e.Module != "example.com/tool" || e.Version != "v1.0.0" || e.GoVersion != "1.21" || e.Toolchain != "local" || e.Binary != filepath.Join(gobin, e.Tool) {
+				t.Errorf("installed event = %+v", e)
+			}
+		case "fail":
+			if e.Error == "" {
+				t.Errorf("fail event without an error: %+v", e)
+			}
+		}
+	}
+	// Only one of the installations downloads the module zip, which
+	// fails once.
+	retried := slices.Contains(kinds["hello"], "retry") != slices.Contains(kinds["renamed"], "retry")
+	if !retried {
+		t.Errorf("events = %v, want one retry", kinds)
+	}
+	for _, tool := range []string{"hello", "renamed"} {
+		if k := kinds[tool]; len(k) == 0 || k[0] != "start" || k[len(k)-1] != "installed" {
+			t.Errorf("%s events = %v, want start ... installed", tool, k)
+		}
+	}
+	if k := kinds["x"]; !slices.Equal(k, []string{"start", "fail"}) {
+		t.Errorf("x events = %v, want start fail", k)
+	}
+
+	entries, err := os.ReadDir(gobin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if want := []string{"hello", "renamed"}; !slices.Equal(names, want) {
+		t.Errorf("GOBIN contains %v, want %v", names, want)
+	}
+}
@@ -0,0 +1,796 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const testifySuitePath = "github.com/stretchr/testify/suite"
+
+// TestPackage lists the tests found in the _test.go files of a directory.
+type TestPackage struct {
+	Dir     string
+	Package string // package name, without the _test suffix of external tests
+	Tests   []*TestItem
+	Suites  []*TestSuite `json:",omitempty"`
+	Errors  []string     `json:",omitempty"` // parse errors; the tests of the parsable parts are listed
+}
+
+// TestItem is a test, benchmark, fuzz test, example, TestMain, testify
+// suite method or subtest. Name is the name go test reports, such as
+// TestFoo/sub_case, which is also what -run matches.
+//
+// Kind is one of test, benchmark, fuzz, example, main, method (a
+// testify suite method, run as a subtest of the test that runs the
+// suite) or subtest.
+type TestItem struct {
+	Name     string
+	Kind     string
+	File     string
+	Line     int
+	Col      int
+	EndLine  int
+	Receiver string      `json:",omitempty"` // suite type, for methods
+	Children []*TestItem `json:",omitempty"`
+}
+
+// TestSuite is a testify suite: a struct type that embeds suite.Suite.
+type TestSuite struct {
+	Type    string
+	File    string
+	Line    int
+	Runners []string    // tests that run the suite with suite.Run
+	Methods []*TestItem // test methods, named by their method name
+}
+
+// testFiles holds the parsed test files of a directory.
+type testFiles struct {
+	fset    *token.FileSet
+	files   []*ast.File
+	vars    map[string]ast.Expr        // package-level variables, by name
+	structs map[string]*ast.StructType // package-level struct types, by name
+	// subNames counts the subtests of each name, as the testing
+	// package does to make the names unique.
+	subNames map[string]int32
+}
+
+// isTestName reports whether name is a test function name with the given
+// prefix: the prefix, optionally followed by a name that does not start
+// with a lower case letter. See cmd/go's isTest.
+func isTestName(name, prefix string) bool {
+	if !strings.HasPrefix(name, prefix) {
+		return false
+	}
+	if len(name) == len(prefix) {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(name[len(prefix):])
+	return !unicode.IsLower(r)
+}
+
+// importName returns the name under which f imports path, or "" if it
+// does not. Dot imports are returned as ".".
+func importName(f *ast.File, path string) string {
+	for _, imp := range f.Imports {
+		if p, _ := strconv.Unquote(imp.Path.Value); p != path {
+			continue
+		}
+		if imp.Name != nil {
+			return imp.Name.Name
+		}
+		return path[strings.LastIndexByte(path, '/')+1:]
+	}
+	return ""
+}
+
+// isQualified reports whether x is the identifier name qualified by the
+// package imported as pkg, or just name if pkg is dot-imported.
+func isQualified(x ast.Expr, pkg, name string) bool {
+	switch x := x.(type) {
+	case *ast.Ident:
+		return pkg == "." && x.Name == name
+	case *ast.SelectorExpr:
+		return pkg != "" && isIdent(x.X, pkg) && x.Sel.Name == name
+	}
+	return false
+}
+
+func isIdent(x ast.Expr, name string) bool {
+	id, ok := x.(*ast.Ident)
+	return ok && id.Name == name
+}
+
+// testParam returns the name of the parameter of fn if fn has a
+// single parameter of type *testing.<typ>, and ok.
+func testParam(fn *ast.FuncType, testing, typ string) (name string, ok bool) {
+	if fn.Params.NumFields() != 1 || fn.Results.NumFields() != 0 || fn.TypeParams.NumFields() != 0 {
+		return "", false
+	}
+	p := fn.Params.List[0]
+	star, ok := p.Type.(*ast.StarExpr)
+	if !ok || !isQualified(star.X, testing, typ) {
+		return "", false
+	}
+	if len(p.Names) == 1 {
+		name = p.Names[0].Name
+	}
+	return name, true
+}
+
+// receiverType returns the name of the type of a method receiver.
+func receiverType(recv *ast.FieldList) string {
+	if recv.NumFields() != 1 {
+		return ""
+	}
+	t := recv.List[0].Type
+	if star, ok := t.(*ast.StarExpr); ok {
+		t = star.X
+	}
+	if id, ok := t.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
+
+func (tf *testFiles) item(name, kind string, n ast.Node) *TestItem {
+	start := tf.fset.Position(n.Pos())
+	return &TestItem{
+		Name:    name,
+		Kind:    kind,
+		File:    start.Filename,
+		Line:    start.Line,
+		Col:     start.Column,
+		EndLine: tf.fset.Position(n.End()).Line,
+	}
+}
+
+// discoverTests lists the tests of the _test.go files of dir.
+// It returns nil if dir has no test files.
+func discoverTests(dir string) (*TestPackage, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	tf := &testFiles{
+		fset:     token.NewFileSet(),
+		vars:     map[string]ast.Expr{},
+		structs:  map[string]*ast.StructType{},
+		subNames: map[string]int32{},
+	}
+	pkg := &TestPackage{Dir: dir, Tests: []*TestItem{}}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, "_test.go") || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+			continue
+		}
+		f, err := parser.ParseFile(tf.fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			pkg.Errors = append(pkg.Errors, err.Error())
+		}
+		if f == nil {
+			continue
+		}
+		tf.files = append(tf.files, f)
+		if pkg.Package == "" || !strings.HasSuffix(f.Name.Name, "_test") {
+			pkg.Package = strings.TrimSuffix(f.Name.Name, "_test")
+		}
+	}
+	if len(tf.files) == 0 {
+		return nil, nil
+	}
+	tf.collectDecls()
+
+	suites := tf.suites()
+	runners := map[string][]*TestItem{} // suite type -> the tests that run it
+	for _, f := range tf.files {
+		testing := importName(f, "testing")
+		testify := importName(f, testifySuitePath)
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil {
+				continue
+			}
+			name := fn.Name.Name
+			var item *TestItem
+			var param string
+			switch {
+			case isTestName(name, "Test"):
+				// TestMain is a test if it takes a *testing.T.
+				if p, ok := testParam(fn.Type, testing, "T"); ok {
+					item, param = tf.item(name, "test", fn), p
+				} else if _, ok := testParam(fn.Type, testing, "M"); ok && name == "TestMain" {
+					item = tf.item(name, "main", fn)
+				}
+			case isTestName(name, "Benchmark"):
+				if p, ok := testParam(fn.Type, testing, "B"); ok {
+					item, param = tf.item(name, "benchmark", fn), p
+				}
+			case isTestName(name, "Fuzz"):
+				if p, ok := testParam(fn.Type, testing, "F"); ok {
+					item, param = tf.item(name, "fuzz", fn), p
+				}
+			case isTestName(name, "Example"):
+				if fn.Type.Params.NumFields() == 0 && fn.Type.Results.NumFields() == 0 {
+					item = tf.item(name, "example", fn)
+				}
+			}
+			if item == nil {
+				continue
+			}
+			pkg.Tests = append(pkg.Tests, item)
+			if param == "" || fn.Body == nil {
+				continue
+			}
+			if item.Kind != "fuzz" {
+				tf.subtests(item, fn.Body, fn.Body, param)
+			}
+			if item.Kind == "test" && testify != "" {
+				for _, typ := range tf.suiteRuns(fn.Body, testify) {
+					if s := suites[typ]; s != nil {
+						s.Runners = append(s.Runners, name)
+						runners[typ] = append(runners[typ], item)
+					}
+				}
+			}
+		}
+	}
+
+	for _, typ := range slices.Sorted(maps.Keys(suites)) {
+		s := suites[typ]
+		pkg.Suites = append(pkg.Suites, s.TestSuite)
+		methods := tf.suiteMethods(suites, typ)
+		for _, m := range methods {
+			item := tf.item(m.Name.Name, "method", m)
+			item.Receiver = receiverType(m.Recv)
+			s.Methods = append(s.Methods, item)
+		}
+		for _, r := range runners[typ] {
+			for _, m := range methods {
+				item := tf.item(r.Name+"/"+tf.uniqueSubtest(r.Name, m.Name.Name), "method", m)
+				item.Receiver = receiverType(m.Recv)
+				tf.subtests(item, m.Body, m.Body, suiteReceiverName(m))
+				r.Children = append(r.Children, item)
+			}
+		}
+	}
+	return pkg, nil
+}
+
+// collectDecls records the package-level variables and struct types,
+// to resolve the tables of table-driven tests.
+func (tf *testFiles) collectDecls() {
+	for _, f := range tf.files {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				switch spec := spec.(type) {
+				case *ast.ValueSpec:
+					for i, n := range spec.Names {
+						if i < len(spec.Values) {
+							tf.vars[n.Name] = spec.Values[i]
+						}
+					}
+				case *ast.TypeSpec:
+					if st, ok := spec.Type.(*ast.StructType); ok {
+						tf.structs[spec.Name.Name] = st
+					}
+				}
+			}
+		}
+	}
+}
+
+type suiteDecl struct {
+	*TestSuite
+	embeds []string // embedded types declared in the package
+}
+
+// suites returns the testify suites declared in the test files: the
+// struct types that embed suite.Suite, or another suite.
+func (tf *testFiles) suites() map[string]*suiteDecl {
+	all := map[string]*suiteDecl{}
+	isSuite := map[string]bool{}
+	for _, f := range tf.files {
+		testify := importName(f, testifySuitePath)
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				st, ok := ts.Type.(*ast.StructType)
+				if !ok {
+					continue
+				}
+				pos := tf.fset.Position(ts.Pos())
+				d := &suiteDecl{TestSuite: &TestSuite{Type: ts.Name.Name, File: pos.Filename, Line: pos.Line, Runners: []string{}, Methods: []*TestItem{}}}
+				for _, field := range st.Fields.List {
+					if len(field.Names) > 0 {
+						continue
+					}
+					t := field.Type
+					if star, ok := t.(*ast.StarExpr); ok {
+						t = star.X
+					}
+					if isQualified(t, testify, "Suite") {
+						isSuite[ts.Name.Name] = true
+					} else if id, ok := t.(*ast.Ident); ok {
+						d.embeds = append(d.embeds, id.Name)
+					}
+				}
+				all[ts.Name.Name] = d
+			}
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for name, d := range all {
+			if isSuite[name] {
+				continue
+			}
+			for _, e := range d.embeds {
+				if isSuite[e] {
+					isSuite[name], changed = true, true
+					break
+				}
+			}
+		}
+	}
+	for name := range all {
+		if !isSuite[name] {
+			delete(all, name)
+		}
+	}
+	return all
+}
+
+// suiteMethods returns the test methods of the suite typ, including
+// those promoted from embedded suites, in source order.
+func (tf *testFiles) suiteMethods(suites map[string]*suiteDecl, typ string) []*ast.FuncDecl {
+	types := map[string]bool{}
+	var add func(string)
+	add = func(t string) {
+		if types[t] || suites[t] == nil {
+			return
+		}
+		types[t] = true
+		for _, e := range suites[t].embeds {
+			add(e)
+		}
+	}
+	add(typ)
+
+	var res []*ast.FuncDecl
+	seen := map[string]bool{}
+	for _, f := range tf.files {
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || fn.Body == nil || !types[receiverType(fn.Recv)] {
+				continue
+			}
+			// testify runs the exported methods whose names start with
+			// Test and that take no arguments.
+			name := fn.Name.Name
+			if !strings.HasPrefix(name, "Test") || fn.Type.Params.NumFields() != 0 || seen[name] {
+				continue
+			}
+			seen[name] = true
+			res = append(res, fn)
+		}
+	}
+	return res
+}
+
+// suiteReceiverName returns the name of the receiver of a suite method,
+// through which it calls Run to start subtests.
+func suiteReceiverName(fn *ast.FuncDecl) string {
+	if names := fn.Recv.List[0].Names; len(names) == 1 {
+		return names[0].Name
+	}
+	return ""
+}
+
+// suiteRuns returns the suite types run by calls to suite.Run in body,
+// such as suite.Run(t, new(MySuite)) or suite.Run(t, &MySuite{}).
+func (tf *testFiles) suiteRuns(body *ast.BlockStmt, testify string) []string {
+	var types []string
+	ast.Inspect(body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || !isQualified(call.Fun, testify, "Run") || len(call.Args) != 2 {
+			return true
+		}
+		x := tf.resolve(call.Args[1], body)
+		if u, ok := x.(*ast.UnaryExpr); ok && u.Op == token.AND {
+			x = u.X
+		}
+		switch x := x.(type) {
+		case *ast.CallExpr:
+			if isIdent(x.Fun, "new") && len(x.Args) == 1 {
+				if id, ok := x.Args[0].(*ast.Ident); ok {
+					types = append(types, id.Name)
+				}
+			}
+		case *ast.CompositeLit:
+			if id, ok := x.Type.(*ast.Ident); ok {
+				types = append(types, id.Name)
+			}
+		}
+		return true
+	})
+	return types
+}
+
+// subtests adds to parent the subtests started in body by calls to
+// Run on the variable tvar, with a name that can be determined
+// statically: a string literal, or a field or key of the elements of
+// a table literal ranged over by an enclosing loop. scope is the body
+// of the enclosing function declaration, in which tables are looked up.
+func (tf *testFiles) subtests(parent *TestItem, scope *ast.BlockStmt, body ast.Node, tvar string) {
+	if tvar == "" || tvar == "_" {
+		return
+	}
+	ast.PreorderStack(body, nil, func(n ast.Node, stack []ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || sel.Sel.Name != "Run" || !isIdent(sel.X, tvar) || len(call.Args) != 2 {
+			return true
+		}
+		lit, _ := call.Args[1].(*ast.FuncLit)
+		for _, sub := range tf.subtestNames(call.Args[0], stack, scope) {
+			item := tf.item(parent.Name+"/"+tf.uniqueSubtest(parent.Name, sub.name), "subtest", call)
+			if sub.pos.IsValid() {
+				pos := tf.fset.Position(sub.pos)
+				item.Line, item.Col, item.EndLine = pos.Line, pos.Column, tf.fset.Position(sub.end).Line
+			}
+			if lit != nil {
+				// The function passed to a testify suite's Run takes
+				// no arguments: its subtests are started through the
+				// suite as well.
+				inner := tvar
+				if lit.Type.Params.NumFields() == 1 {
+					inner = ""
+					if names := lit.Type.Params.List[0].Names; len(names) == 1 {
+						inner = names[0].Name
+					}
+				}
+				tf.subtests(item, scope, lit.Body, inner)
+			}
+			parent.Children = append(parent.Children, item)
+		}
+		return false
+	})
+}
+
+type subtestName struct {
+	name     string
+	pos, end token.Pos // position of the table entry, if the name comes from a table
+}
+
+// subtestNames returns the statically known names of a subtest
+// whose name is given by x, evaluated in the context of stack.
+func (tf *testFiles) subtestNames(x ast.Expr, stack []ast.Node, scope *ast.BlockStmt) []subtestName {
+	if s, ok := stringLit(x); ok {
+		return []subtestName{{name: s}}
+	}
+
+	// Find the loop over a table that defines the variable used.
+	var v, field string
+	switch x := x.(type) {
+	case *ast.Ident:
+		v = x.Name
+	case *ast.SelectorExpr:
+		id, ok := x.X.(*ast.Ident)
+		if !ok {
+			return nil
+		}
+		v, field = id.Name, x.Sel.Name
+	default:
+		return nil
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		rs, ok := stack[i].(*ast.RangeStmt)
+		if !ok {
+			continue
+		}
+		isKey, isValue := rs.Key != nil && isIdent(rs.Key, v), rs.Value != nil && isIdent(rs.Value, v)
+		if !isKey && !isValue {
+			continue
+		}
+		table, ok := tf.resolve(rs.X, scope).(*ast.CompositeLit)
+		if !ok {
+			return nil
+		}
+		var eltType ast.Expr
+		isMap := false
+		switch t := table.Type.(type) {
+		case *ast.ArrayType:
+			eltType = t.Elt
+		case *ast.MapType:
+			eltType, isMap = t.Value, true
+		default:
+			return nil
+		}
+		if isKey && (!isMap || field != "") {
+			return nil
+		}
+
+		var res []subtestName
+		for _, elt := range table.Elts {
+			e := elt
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				e = kv.Value
+				if isKey {
+					e = kv.Key
+				}
+			}
+			if field != "" {
+				e = tf.fieldValue(e, eltType, field)
+			}
+			if s, ok := stringLit(e); ok {
+				res = append(res, subtestName{s, elt.Pos(), elt.End()})
+			}
+		}
+		return res
+	}
+	return nil
+}
+
+// resolve returns the value of x if it is a variable initialized in
+// scope or at package level, or else x itself.
+func (tf *testFiles) resolve(x ast.Expr, scope *ast.BlockStmt) ast.Expr {
+	id, ok := ast.Unparen(x).(*ast.Ident)
+	if !ok {
+		return ast.Unparen(x)
+	}
+	var val ast.Expr
+	ast.Inspect(scope, func(n ast.Node) bool {
+		if val != nil {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if len(n.Lhs) == len(n.Rhs) {
+				for i, lhs := range n.Lhs {
+					if isIdent(lhs, id.Name) {
+						val = n.Rhs[i]
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			for i, name := range n.Names {
+				if name.Name == id.Name && i < len(n.Values) {
+					val = n.Values[i]
+				}
+			}
+		case *ast.FuncLit:
+			return false
+		}
+		return true
+	})
+	if val == nil {
+		val = tf.vars[id.Name]
+	}
+	if val == nil {
+		return id
+	}
+	return ast.Unparen(val)
+}
+
+// fieldValue returns the value of the named field in the table
+// element e, a composite literal of type typ, or nil.
+func (tf *testFiles) fieldValue(e, typ ast.Expr, field string) ast.Expr {
+	if u, ok := e.(*ast.UnaryExpr); ok && u.Op == token.AND {
+		e = u.X
+	}
+	lit, ok := e.(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+	if lit.Type != nil {
+		typ = lit.Type
+	}
+	for _, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			if isIdent(kv.Key, field) {
+				return kv.Value
+			}
+			continue
+		}
+		// Positional fields.
+		i := tf.fieldIndex(typ, field)
+		if i < 0 || i >= len(lit.Elts) {
+			return nil
+		}
+		return lit.Elts[i]
+	}
+	return nil
+}
+
+// fieldIndex returns the index of the named field in the struct type typ.
+func (tf *testFiles) fieldIndex(typ ast.Expr, field string) int {
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	var st *ast.StructType
+	switch t := typ.(type) {
+	case *ast.StructType:
+		st = t
+	case *ast.Ident:
+		st = tf.structs[t.Name]
+	}
+	if st == nil {
+		return -1
+	}
+	i := 0
+	for _, f := range st.Fields.List {
+		if len(f.Names) == 0 {
+			i++
+			continue
+		}
+		for _, n := range f.Names {
+			if n.Name == field {
+				return i
+			}
+			i++
+		}
+	}
+	return -1
+}
+
+func stringLit(x ast.Expr) (string, bool) {
+	lit, ok := x.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
+
+// uniqueSubtest returns the name the testing package gives a subtest
+// named name of parent: the name rewritten to printable characters
+// without spaces, followed by a #NN suffix if the name is a duplicate.
+// It mirrors testing's matcher.unique.
+func (tf *testFiles) uniqueSubtest(parent, name string) string {
+	name = rewriteSubtestName(name)
+	base := parent + "/" + name
+	for {
+		n := tf.subNames[base]
+		tf.subNames[base] = n + 1
+		if n == 0 && name != "" {
+			prefix, nn := parseSubtestNumber(base)
+			if len(prefix) < len(base) && nn < tf.subNames[prefix] {
+				continue
+			}
+			return name
+		}
+		unique := fmt.Sprintf("%s#%02d", base, n)
+		if tf.subNames[unique] != 0 {
+			continue
+		}
+		return unique[len(parent)+1:]
+	}
+}
+
+// parseSubtestNumber splits a subtest name into a "#%02d"-formatted
+// suffix, if present, and the prefix preceding it.
+// It mirrors testing's function of the same name.
+func parseSubtestNumber(s string) (prefix string, nn int32) {
+	i := strings.LastIndex(s, "#")
+	if i < 0 {
+		return s, 0
+	}
+	prefix, suffix := s[:i], s[i+1:]
+	if len(suffix) < 2 || (len(suffix) > 2 && suffix[0] == '0') {
+		return s, 0
+	}
+	if suffix == "00" && !strings.HasSuffix(prefix, "/") {
+		return s, 0
+	}
+	n, err := strconv.ParseInt(suffix, 10, 32)
+	if err != nil || n < 0 {
+		return s, 0
+	}
+	return prefix, int32(n)
+}
+
+// rewriteSubtestName rewrites a subtest name as the testing package
+// does: white space becomes underscores and non-printable characters
+// are escaped as in Go string literals.
+func rewriteSubtestName(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case isTestingSpace(r):
+			b.WriteByte('_')
+		case !strconv.IsPrint(r):
+			q := strconv.QuoteRune(r)
+			b.WriteString(q[1 : len(q)-1])
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// isTestingSpace reports whether the testing package
+// considers r a space. This is not the Unicode Z class.
+func isTestingSpace(r rune) bool {
+	if r < 0x2000 {
+		switch r {
+		case '\t', '\n', '\v', '\f', '\r', ' ', 0x85, 0xA0, 0x1680:
+			return true
+		}
+		return false
+	}
+	if r <= 0x200a {
+		return true
+	}
+	switch r {
+	case 0x2028, 0x2029, 0x202f, 0x205f, 0x3000:
+		return true
+	}
+	return false
+}
+
+// walkTestPackages calls f with the tests of each directory in the tree
+// rooted at root that has test files. Like the go command, it skips
+// testdata and vendor directories and those whose names begin with
+// "." or "_".
+func walkTestPackages(root string, f func(*TestPackage) error) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if name := d.Name(); path != root && (name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+			return filepath.SkipDir
+		}
+		pkg, err := discoverTests(path)
+		if err != nil || pkg == nil {
+			return err
+		}
+		return f(pkg)
+	})
+}
+
+func runListTests(args []string) error {
+	root := "."
+	switch len(args) {
+	case 0:
+	case 1:
+		root = args[0]
+	default:
+		return fmt.Errorf("usage: list-tests [<dir>]")
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	return walkTestPackages(root, func(pkg *TestPackage) error { return enc.Encode(pkg) })
+}
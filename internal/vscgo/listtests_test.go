@@ -0,0 +1,166 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+const listTestsSource = `package p_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestMain(m *testing.M) {}
+
+func TestTable(t *testing.T) {
+	tests := []struct {
+		name string
+		in   int
+	}{
+		{name: "one", in: 1},
+		{"two words", 2},
+		{name: "one", in: 3},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Run("inner", func(t *testing.T) {})
+		})
+	}
+}
+
+func TestMap(t *testing.T) {
+	for name, want := range map[string]int{"a": 1, "b": 2} {
+		t.Run(name, func(t *testing.T) { _ = want })
+	}
+}
+
+func TestDynamic(t *testing.T) {
+	for i := range 3 {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {})
+	}
+}
+
+func Testlower(t *testing.T) {}
+func TestHelper(x int)        {}
+
+func BenchmarkB(b *testing.B) {
+	b.Run("small", func(b *testing.B) {})
+}
+
+func FuzzF(f *testing.F) {}
+
+func Example() {}
+
+type base struct {
+	suite.Suite
+}
+
+func (s *base) TestBase() {}
+
+type mySuite struct {
+	base
+}
+
+func (s *mySuite) TestMethod() {
+	s.Run("sub", func() {})
+}
+
+func (s *mySuite) helper() {}
+
+func TestSuite(t *testing.T) {
+	suite.Run(t, new(mySuite))
+}
+`
+
+func TestDiscoverTests(t *testing.T) {
+	dir := writeFiles(t, t.TempDir(), map[string]string{
+		"p_test.go":          listTestsSource,
+		"p.go":               "package p\n\nfunc TestNotATest(t *testing.T) {}\n",
+		"testdata/x_test.go": "package x\n\nfunc TestIgnored(t *testing.T) {}\n",
+		"sub/s_test.go":      "package sub\n\nimport \"testing\"\n\nfunc TestSub(t *testing.T) {}\n",
+	})
+
+	var pkgs []*TestPackage
+	if err := walkTestPackages(dir, func(p *TestPackage) error {
+		pkgs = append(pkgs, p)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(pkgs) != 2 || pkgs[0].Dir != dir || pkgs[1].Dir != filepath.Join(dir, "sub") {
+		t.Fatalf("packages = %+v, want %s and its sub directory", pkgs, dir)
+	}
+	p := pkgs[0]
+	if p.Package != "p" {
+		t.Errorf("package = %q, want p", p.Package)
+	}
+
+	var got []string
+	var walk func(items []*TestItem)
+	walk = func(items []*TestItem) {
+		for _, it := range items {
+			got = append(got, it.Kind+" "+it.Name)
+			walk(it.Children)
+		}
+	}
+	walk(p.Tests)
+	want := []string{
+		"main TestMain",
+		"test TestTable",
+		"subtest TestTable/one",
+		"subtest TestTable/one/inner",
+		"subtest TestTable/two_words",
+		"subtest TestTable/two_words/inner",
+		"subtest TestTable/one#01",
+		"subtest TestTable/one#01/inner",
+		"test TestMap",
+		"subtest TestMap/a",
+		"subtest TestMap/b",
+		"test TestDynamic",
+		"benchmark BenchmarkB",
+		"subtest BenchmarkB/small",
+		"fuzz FuzzF",
+		"example Example",
+		"test TestSuite",
+		"method TestSuite/TestBase",
+		"method TestSuite/TestMethod",
+		"subtest TestSuite/TestMethod/sub",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("tests =\n%q\nwant\n%q", got, want)
+	}
+
+	// The table entry locates a table-driven subtest.
+	if sub := p.Tests[1].Children[1]; sub.Line != 17 {
+		t.Errorf("%s at line %d, want 17", sub.Name, sub.Line)
+	}
+
+	if len(p.Suites) != 2 {
+		t.Fatalf("suites = %+v, want base and mySuite", p.Suites)
+	}
+	if s := p.Suites[1]; s.Type != "mySuite" || !slices.Equal(s.Runners, []string{"TestSuite"}) || len(s.Methods) != 2 {
+		t.Errorf("mySuite = %+v, want 2 methods run by TestSuite", s)
+	}
+}
+
+func TestRewriteSubtestName(t *testing.T) {
+	for in, want := range map[string]string{
+		"a b":       "a_b",
+		"tab\there": "tab_here",
+		"nul\x00":   `nul\x00`,
+		"ünïcode":   "ünïcode",
+		"a b":       "a_b",
+	} {
+		if got := rewriteSubtestName(in); got != want {
+			t.Errorf("rewriteSubtestName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
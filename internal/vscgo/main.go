@@ -44,17 +44,159 @@ func init() {
 			run:   runIncCounters,
 		},
 		{
-			usage:   "dump-pprof <profile>",
+			usage:   "dump-pprof [-format json|speedscope|chrome] [-normalize] [-labels <key,...>] <profile>",
 			short:   "convert a pprof profile to a JSON file",
+			flags:   pprofDumpFlags,
 			hasArgs: true,
 			run:     runPprofDump,
 		},
 		{
-			usage:   "serve-pprof <addr> <profile>",
+			usage:   "serve-pprof [-store] <addr> <profile>",
 			short:   "serve a pprof profile",
+			flags:   pprofServeFlags,
 			hasArgs: true,
 			run:     runPprofServe,
 		},
+		{
+			usage:   "profiles [flags] add|list|prune|compare ...",
+			short:   "manage the profile store",
+			flags:   profilesFlags,
+			hasArgs: true,
+			run:     runProfiles,
+		},
+		{
+			usage:   "gopls-profile [flags] <gopls debug address>",
+			short:   "capture profiles from a gopls debug server",
+			flags:   goplsProfileFlags,
+			hasArgs: true,
+			run:     runGoplsProfile,
+		},
+		{
+			usage:   "cover [-C dir] [-format json|func|lcov|cobertura] <profile>...",
+			short:   "merge cover profiles and report their coverage",
+			flags:   coverFlags,
+			hasArgs: true,
+			run:     runCover,
+		},
+		{
+			usage:   "cover-diff [-C dir] [-base-rev rev] <base profile> <head profile>",
+			short:   "report the coverage changes between two cover profiles",
+			flags:   coverDiffFlags,
+			hasArgs: true,
+			run:     runCoverDiff,
+		},
+		{
+			usage:   "test-coverage [flags] [<package>]",
+			short:   "map the tests of a package to the code they cover",
+			flags:   testCoverFlags,
+			hasArgs: true,
+			run:     runTestCoverage,
+		},
+		{
+			usage: "test-events",
+			short: "normalize a go test -json stream read from stdin",
+			run:   runTestEvents,
+		},
+		{
+			usage:   "test-report [-format markdown|junit] [-slowest n] [<file>...]",
+			short:   "summarize go test -json streams as Markdown or JUnit XML",
+			flags:   testReportFlags,
+			hasArgs: true,
+			run:     runTestReport,
+		},
+		{
+			usage:   "list-tests [<dir>]",
+			short:   "list the tests of the packages in a directory tree without building them",
+			hasArgs: true,
+			run:     runListTests,
+		},
+		{
+			usage:   "run-pattern [-flag run|bench|skip] <test path>...",
+			short:   "build a go test pattern that matches exactly the given tests",
+			flags:   runPatternFlags,
+			hasArgs: true,
+			run:     runRunPattern,
+		},
+		{
+			usage:   "test [flags] [<packages>] [-- <go test flags>]",
+			short:   "run go test -json on packages in parallel, and merge their events",
+			flags:   testRunFlags,
+			hasArgs: true,
+			run:     runTest,
+		},
+		{
+			usage:   "flaky [flags] <package> [flags]",
+			short:   "run tests repeatedly and report how often and how they fail",
+			flags:   flakyFlags,
+			hasArgs: true,
+			run:     runFlaky,
+		},
+		{
+			usage:   "fuzz-corpus [flags] list|decode|encode ...",
+			short:   "list, decode and encode fuzz corpus entries",
+			flags:   fuzzCorpusFlags,
+			hasArgs: true,
+			run:     runFuzzCorpus,
+		},
+		{
+			usage:   "fuzz [flags] <package> <fuzz test>",
+			short:   "fuzz a fuzz test within a budget and report its progress and crashes",
+			flags:   fuzzFlags,
+			hasArgs: true,
+			run:     runFuzz,
+		},
+		{
+			usage:   "affected [flags] [<file>...]",
+			short:   "list the packages and tests affected by changed files",
+			flags:   affectedFlags,
+			hasArgs: true,
+			run:     runAffected,
+		},
+		{
+			usage:   "race-report [<file>...]",
+			short:   "parse the data race reports in go test -race output",
+			hasArgs: true,
+			run:     runRaceReport,
+		},
+		{
+			usage: "ps [-all]",
+			short: "list the running Go programs with their build information and listening ports",
+			flags: psFlags,
+			run:   runPs,
+		},
+		{
+			usage:   "inspect-binary <executable>",
+			short:   "report the build information and debugging readiness of a Go executable",
+			hasArgs: true,
+			run:     runInspectBinary,
+		},
+		{
+			usage:   "install-tools [flags] [name=]<import path>[@version]...",
+			short:   "install tools concurrently, each with a toolchain recent enough for it",
+			flags:   installToolsFlags,
+			hasArgs: true,
+			run:     runInstallTools,
+		},
+		{
+			usage:   "outdated-tools [flags] <tool binary>...",
+			short:   "report the tools with a newer version, or built with an older Go",
+			flags:   outdatedToolsFlags,
+			hasArgs: true,
+			run:     runOutdatedTools,
+		},
+		{
+			usage:   "sdk [flags] list|install|remove|verify [<version>]",
+			short:   "list, install, remove or verify Go SDKs from a download index",
+			flags:   sdkFlags,
+			hasArgs: true,
+			run:     runSDK,
+		},
+		{
+			usage: "toolchain [-C <dir>]",
+			short: "explain which Go toolchain the go command runs in a directory",
+			flags: toolchainFlags,
+			run:   runToolchain,
+		},
 		{
 			usage: "version",
 			short: "print version information",
@@ -0,0 +1,293 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"context"
+	"debug/buildinfo"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/version"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+var (
+	outdatedToolsFlags      = flag.NewFlagSet("outdated-tools", flag.ExitOnError)
+	outdatedToolsGo         = outdatedToolsFlags.String("go", "", "Go version the tools should be built with (default: that of the go command)")
+	outdatedToolsProxy      = outdatedToolsFlags.String("proxy", "", "module proxies to query, in the GOPROXY syntax (default: GOPROXY)")
+	outdatedToolsPrerelease = outdatedToolsFlags.Bool("prerelease", false, "consider prerelease versions as updates")
+)
+
+// OutdatedTool is the update status of a tool binary.
+type OutdatedTool struct {
+	Binary    string
+	Path      string `json:",omitempty"` // the main package path
+	Module    string `json:",omitempty"`
+	Version   string `json:",omitempty"` // the version of Module the tool was built from
+	Latest    string `json:",omitempty"` // the latest version of Module, per the prerelease policy
+	Outdated  bool   // Version is older than Latest
+	GoVersion string `json:",omitempty"` // the Go version the tool was built with
+	// TooOldGo reports whether the tool should be rebuilt with the Go
+	// version it is compared to: it was built with an older Go
+	// release, with another prerelease of the same one, or with a Go
+	// version that cannot be determined.
+	TooOldGo bool
+	Error    string `json:",omitempty"`
+}
+
+// errModuleNotFound is returned by a proxy that does not have a module,
+// which lets the next one in the GOPROXY list be queried.
+var errModuleNotFound = errors.New("module not found")
+
+// moduleProxy queries module proxies, as listed in GOPROXY.
+type moduleProxy struct {
+	proxies string
+	client  *http.Client
+}
+
+// get returns the file of the module mod, such as @v/list, from the
+// first proxy that has it. As with the go command, a proxy followed by
+// a comma is only skipped if it does not have the module, and one
+// followed by a pipe is skipped on any error.
+func (p *moduleProxy) get(ctx context.Context, mod, file string) ([]byte, error) {
+	escaped, err := module.EscapePath(mod)
+	if err != nil {
+		return nil, err
+	}
+	err = fmt.Errorf("no module proxy in %q", p.proxies)
+	for proxies := p.proxies; proxies != ""; {
+		i := strings.IndexAny(proxies, ",|")
+		entry, fallback := proxies, false
+		if i >= 0 {
+			entry, fallback = proxies[:i], proxies[i] == '|'
+			proxies = proxies[i+1:]
+		} else {
+			proxies = ""
+		}
+		switch entry = strings.TrimSpace(entry); entry {
+		case "":
+			continue
+		case "off":
+			return nil, fmt.Errorf("module lookup disabled by GOPROXY=off")
+		case "direct":
+			// Version control systems are not queried.
+			return nil, fmt.Errorf("%s: no module proxy has the module before direct", mod)
+		}
+		var data []byte
+		data, err = p.fetch(ctx, strings.TrimSuffix(entry, "/")+"/"+escaped+"/"+file)
+		if err == nil {
+			return data, nil
+		}
+		if !fallback && !errors.Is(err, errModuleNotFound) {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+func (p *moduleProxy) fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "file" {
+		name, err := urlFilePath(runtime.GOOS, u)
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(name)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%s: %w", rawURL, errModuleNotFound)
+		}
+		return data, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound, http.StatusGone:
+		return nil, fmt.Errorf("%s: %w", rawURL, errModuleNotFound)
+	}
+	return nil, fmt.Errorf("%s: %s", rawURL, resp.Status)
+}
+
+// urlFilePath returns the path on goos of the file URL u, as the go
+// command converts it: file:///dir/file is /dir/file, and on Windows,
+// file:///C:/dir/file is C:\dir\file.
+func urlFilePath(goos string, u *url.URL) (string, error) {
+	if u.Host != "" && u.Host != "localhost" {
+		return "", fmt.Errorf("%s: file URL with a non-local host", u)
+	}
+	if !strings.HasPrefix(u.Path, "/") {
+		return "", fmt.Errorf("%s: file URL with a relative path", u)
+	}
+	if goos != "windows" {
+		return u.Path, nil
+	}
+	// The path starts with a slash before the drive letter.
+	name := u.Path[1:]
+	if len(name) < 2 || name[1] != ':' || !('a' <= name[0]|0x20 && name[0]|0x20 <= 'z') {
+		return "", fmt.Errorf("%s: file URL without a drive letter", u)
+	}
+	return strings.ReplaceAll(name, "/", `\`), nil
+}
+
+// latestVersion returns the latest version of mod: the highest release,
+// or if there is none, or prerelease is set, the highest version. For
+// modules without tagged versions, it is that of the proxy's @latest.
+func (p *moduleProxy) latestVersion(ctx context.Context, mod string, prerelease bool) (string, error) {
+	list, err := p.get(ctx, mod, "@v/list")
+	if err != nil {
+		return "", err
+	}
+	var latest, latestRelease string
+	for v := range strings.FieldsSeq(string(list)) {
+		if !semver.IsValid(v) {
+			continue
+		}
+		if semver.Compare(v, latest) > 0 {
+			latest = v
+		}
+		if semver.Prerelease(v) == "" && semver.Compare(v, latestRelease) > 0 {
+			latestRelease = v
+		}
+	}
+	if latestRelease != "" && !prerelease {
+		return latestRelease, nil
+	}
+	if latest != "" {
+		return latest, nil
+	}
+	data, err := p.get(ctx, mod, "@latest")
+	if err != nil {
+		return "", err
+	}
+	var info struct{ Version string }
+	if err := json.Unmarshal(data, &info); err != nil {
+		return "", fmt.Errorf("%s@latest: %v", mod, err)
+	}
+	return info.Version, nil
+}
+
+// builtWithTooOldGo reports whether a tool built with Go version built,
+// as in its build information, should be rebuilt with Go version want.
+func builtWithTooOldGo(built, want string) bool {
+	if strings.HasPrefix(want, "devel ") {
+		// Development versions are not compared.
+		return false
+	}
+	want = goVersionOf(want)
+	if !version.IsValid(want) {
+		return false
+	}
+	built = goVersionOf(built)
+	if !version.IsValid(built) {
+		return true
+	}
+	if c := version.Compare(version.Lang(built), version.Lang(want)); c != 0 {
+		return c < 0
+	}
+	// A tool built with a prerelease is rebuilt with any other version
+	// of the same release.
+	return built != want && isGoPrerelease(built)
+}
+
+// isGoPrerelease reports whether v, such as go1.22rc1, is a prerelease.
+func isGoPrerelease(v string) bool {
+	suffix := strings.TrimPrefix(v, version.Lang(v))
+	return suffix != "" && !strings.HasPrefix(suffix, ".")
+}
+
+// goVersionOf returns the Go version in a version string, as in
+// "go1.22.1 X:nocoverageredesign" or "devel go1.23-41f485b9a7 ...".
+func goVersionOf(s string) string {
+	s = strings.TrimPrefix(s, "devel ")
+	s, _, _ = strings.Cut(s, " ")
+	s, _, _ = strings.Cut(s, "-")
+	return s
+}
+
+// checkTool returns the update status of the tool binary bin.
+func checkTool(ctx context.Context, p *moduleProxy, bin, goVersion string, prerelease bool, latest map[string]string) *OutdatedTool {
+	t := &OutdatedTool{Binary: bin}
+	info, err := buildinfo.ReadFile(bin)
+	if err != nil {
+		t.Error = err.Error()
+		t.TooOldGo = true
+		return t
+	}
+	t.Path, t.Module, t.Version, t.GoVersion = info.Path, info.Main.Path, info.Main.Version, info.GoVersion
+	t.TooOldGo = builtWithTooOldGo(info.GoVersion, goVersion)
+	if t.Module == "" || !semver.IsValid(t.Version) {
+		// The tool was not built from a module version, as
+		// with go build in its module: it has version (devel).
+		return t
+	}
+	l, ok := latest[t.Module]
+	if !ok {
+		l, err = p.latestVersion(ctx, t.Module, prerelease)
+		if err != nil {
+			t.Error = err.Error()
+			return t
+		}
+		latest[t.Module] = l
+	}
+	t.Latest = l
+	t.Outdated = semver.Compare(t.Version, l) < 0
+	return t
+}
+
+func runOutdatedTools(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: outdated-tools [flags] <tool binary>...")
+	}
+	ctx := context.Background()
+	goVersion, proxies := *outdatedToolsGo, *outdatedToolsProxy
+	if goVersion == "" || proxies == "" {
+		out, err := goCommand(ctx, "", nil, "env", "GOVERSION", "GOPROXY")
+		if err != nil {
+			return fmt.Errorf("go env: %v: %s", err, out)
+		}
+		env := strings.Split(string(out), "\n")
+		if len(env) < 2 {
+			return fmt.Errorf("unexpected go env output: %s", out)
+		}
+		if goVersion == "" {
+			goVersion = env[0]
+		}
+		if proxies == "" {
+			proxies = env[1]
+		}
+	}
+	if !strings.HasPrefix(goVersion, "go") && !strings.HasPrefix(goVersion, "devel ") {
+		goVersion = "go" + goVersion
+	}
+	p := &moduleProxy{proxies: proxies, client: &http.Client{Timeout: 30 * time.Second}}
+	latest := map[string]string{}
+	tools := []*OutdatedTool{}
+	for _, bin := range args {
+		tools = append(tools, checkTool(ctx, p, bin, goVersion, *outdatedToolsPrerelease, latest))
+	}
+	return json.NewEncoder(os.Stdout).Encode(tools)
+}
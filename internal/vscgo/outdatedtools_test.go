@@ -0,0 +1,166 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestBuiltWithTooOldGo(t *testing.T) {
+	for _, test := range []struct {
+		built, want string
+		tooOld      bool
+	}{
+		{"go1.22.1", "go1.22.5", false},
+		{"go1.22.5", "go1.22.1", false},
+		{"go1.21.0", "go1.22.1", true},
+		{"go1.23.0", "go1.22.1", false},
+		{"go1.22rc1", "go1.22.0", true},
+		{"go1.22rc1", "go1.22rc2", true},
+		{"go1.22rc1", "go1.22rc1", false},
+		{"go1.22.0", "go1.22rc1", false},
+		{"go1.22.1 X:nocoverageredesign", "go1.22.1", false},
+		{"devel go1.22-41f485b9a7 Mon Jan 31 13:43:52 2022 +0000", "go1.22.1", false},
+		{"go1.22rc1", "devel go1.22-41f485b9a7", false},
+		{"", "go1.22.1", true},
+	} {
+		if got := builtWithTooOldGo(test.built, test.want); got != test.tooOld {
+			t.Errorf("builtWithTooOldGo(%q, %q) = %v, want %v", test.built, test.want, got, test.tooOld)
+		}
+	}
+}
+
+// fileURL returns the file URL of the absolute path dir, such as
+// file:///tmp/proxy, or file:///C:/proxy on Windows.
+func fileURL(dir string) string {
+	p := filepath.ToSlash(dir)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+func TestURLFilePath(t *testing.T) {
+	for _, test := range []struct {
+		goos, url, want string
+	}{
+		{"linux", "file:///tmp/proxy", "/tmp/proxy"},
+		{"linux", "file://localhost/tmp/proxy", "/tmp/proxy"},
+		{"windows", "file:///C:/proxy/mod", `C:\proxy\mod`},
+		{"windows", "file:///c:/proxy", `c:\proxy`},
+		{"windows", "file:///proxy", ""},
+		{"linux", "file://host/tmp/proxy", ""},
+		{"linux", "file:proxy", ""},
+	} {
+		u, err := url.Parse(test.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := urlFilePath(test.goos, u)
+		if test.want == "" {
+			if err == nil {
+				t.Errorf("urlFilePath(%s, %s) = %q, want an error", test.goos, test.url, got)
+			}
+			continue
+		}
+		if err != nil || got != test.want {
+			t.Errorf("urlFilePath(%s, %s) = %q, %v, want %q", test.goos, test.url, got, err, test.want)
+		}
+	}
+}
+
+func TestModuleProxyFallback(t *testing.T) {
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "broken", http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+	proxy := writeModuleProxy(t, t.TempDir(), map[string]map[string]string{
+		"example.com/Tool@v1.0.0": {"go.mod": "module example.com/Tool\n"},
+	})
+	proxyURL := fileURL(proxy)
+
+	for _, test := range []struct {
+		proxies string
+		ok      bool
+	}{
+		{proxyURL, true},
+		{notFound.URL + "," + proxyURL, true},
+		{broken.URL + "," + proxyURL, false},
+		{broken.URL + "|" + proxyURL, true},
+		{notFound.URL + ",direct", false},
+		{"off", false},
+	} {
+		p := &moduleProxy{proxies: test.proxies, client: http.DefaultClient}
+		// The path of the module is escaped: example.com/!tool.
+		v, err := p.latestVersion(context.Background(), "example.com/Tool", false)
+		if test.ok && (err != nil || v != "v1.0.0") {
+			t.Errorf("GOPROXY=%s: latest version = %q, %v, want v1.0.0", test.proxies, v, err)
+		} else if !test.ok && err == nil {
+			t.Errorf("GOPROXY=%s: latest version = %q, want an error", test.proxies, v)
+		}
+	}
+}
+
+func TestCheckTool(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a tool")
+	}
+	tool := map[string]string{
+		"go.mod":  "module example.com/tool\n\ngo 1.21\n",
+		"main.go": "package main\n\nfunc main() {}\n",
+	}
+	proxy := writeModuleProxy(t, t.TempDir(), map[string]map[string]string{
+		"example.com/tool@v1.0.0":      tool,
+		"example.com/tool@v1.1.0":      tool,
+		"example.com/tool@v1.2.0-rc.1": tool,
+	})
+	proxyURL := fileURL(proxy)
+	gobin := t.TempDir()
+	cmd := exec.Command("go", "install", "example.com/tool@v1.0.0")
+	cmd.Dir = t.TempDir()
+	cmd.Env = append(cmd.Environ(), "GOPROXY="+proxyURL, "GOSUMDB=off", "GOFLAGS=-modcacherw", "GOMODCACHE="+t.TempDir(), "GOBIN="+gobin, "GOTOOLCHAIN=local")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go install: %v\n%s", err, out)
+	}
+	bin := filepath.Join(gobin, "tool")
+	if runtime.GOOS == "windows" {
+		bin += ".exe"
+	}
+
+	p := &moduleProxy{proxies: proxyURL, client: http.DefaultClient}
+	for _, test := range []struct {
+		goVersion  string
+		prerelease bool
+		latest     string
+		tooOld     bool
+	}{
+		{runtime.Version(), false, "v1.1.0", false},
+		{runtime.Version(), true, "v1.2.0-rc.1", false},
+		{"go1.999.0", false, "v1.1.0", true},
+	} {
+		got := checkTool(context.Background(), p, bin, test.goVersion, test.prerelease, map[string]string{})
+		if got.Error != "" || got.Module != "example.com/tool" || got.Version != "v1.0.0" || got.Latest != test.latest || !got.Outdated || got.TooOldGo != test.tooOld {
+			t.Errorf("checkTool(go %s, prerelease %v) = %+v, want v1.0.0 outdated by %s, too old Go %v", test.goVersion, test.prerelease, got, test.latest, test.tooOld)
+		}
+		if !strings.HasPrefix(got.GoVersion, "go") {
+			t.Errorf("tool Go version = %q", got.GoVersion)
+		}
+	}
+
+	got := checkTool(context.Background(), p, filepath.Join(gobin, "missing"), runtime.Version(), false, map[string]string{})
+	if got.Error == "" || !got.TooOldGo {
+		t.Errorf("checkTool of a missing binary = %+v, want an error", got)
+	}
+}
@@ -6,18 +6,34 @@ package vscgo
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
 
 	"github.com/google/pprof/profile"
 )
 
+var (
+	pprofDumpFlags       = flag.NewFlagSet("dump-pprof", flag.ExitOnError)
+	pprofDumpLabels      = pprofDumpFlags.String("labels", "", "comma-separated pprof label keys; if set, print sample values grouped by these labels instead of the profile")
+	pprofDumpSampleIndex = pprofDumpFlags.String("sample_index", "", "sample type, by name or index, used to rank label groups and functions (default: the profile's default sample type)")
+	pprofDumpTop         = pprofDumpFlags.Int("top", 10, "number of top functions to report for each label group")
+	pprofDumpFormat      = pprofDumpFlags.String("format", "json", "output format: json, speedscope, or chrome (Chrome Trace Event format)")
+	pprofDumpNormalize   = pprofDumpFlags.Bool("normalize", false, "with -format=json, add sample values scaled to readable units and derived sample types")
+
+	pprofServeFlags = flag.NewFlagSet("serve-pprof", flag.ExitOnError)
+	pprofServeStore = pprofServeFlags.Bool("store", false, "serve the profile with the given ID from the profile store")
+	pprofServeDir   = pprofServeFlags.String("dir", "", "profile store directory used with -store (default: vscode-go/profiles in the user cache directory)")
+)
+
 func runPprofDump(args []string) error {
 	if len(args) != 1 {
-		return fmt.Errorf("usage: dump-pprof <profile>")
+		return fmt.Errorf("usage: dump-pprof [flags] <profile>")
 	}
 
 	p, err := readPprof(args[0])
@@ -25,24 +41,75 @@ func runPprofDump(args []string) error {
 		return err
 	}
 
-	return json.NewEncoder(os.Stdout).Encode((*Profile)(p))
+	if *pprofDumpLabels != "" {
+		if *pprofDumpFormat != "json" {
+			return fmt.Errorf("-labels requires -format=json")
+		}
+		g, err := labelGroups(p, *pprofDumpLabels, *pprofDumpSampleIndex, *pprofDumpTop)
+		if err != nil {
+			return err
+		}
+		return json.NewEncoder(os.Stdout).Encode(g)
+	}
+
+	switch *pprofDumpFormat {
+	case "json":
+		if *pprofDumpNormalize {
+			return json.NewEncoder(os.Stdout).Encode((*NormalizedProfile)(p))
+		}
+		return json.NewEncoder(os.Stdout).Encode((*Profile)(p))
+	case "speedscope", "chrome":
+		si, err := sampleIndex((*profile.Profile)(p), *pprofDumpSampleIndex)
+		if err != nil {
+			return err
+		}
+		name := filepath.Base(args[0])
+		if *pprofDumpFormat == "speedscope" {
+			return json.NewEncoder(os.Stdout).Encode(toSpeedscope((*profile.Profile)(p), name, si))
+		}
+		tr, err := toChromeTrace((*profile.Profile)(p), name, si)
+		if err != nil {
+			return err
+		}
+		return json.NewEncoder(os.Stdout).Encode(tr)
+	default:
+		return fmt.Errorf("unknown format %q, want json, speedscope, or chrome", *pprofDumpFormat)
+	}
 }
 
 func runPprofServe(args []string) error {
 	if len(args) != 2 {
-		return fmt.Errorf("usage: serve-pprof <addr> <profile>")
+		return fmt.Errorf("usage: serve-pprof [-store] <addr> <profile>")
 	}
 
-	l, err := net.Listen("tcp", args[0])
+	file := args[1]
+	if *pprofServeStore {
+		s, err := openProfileStore(*pprofServeDir)
+		if err != nil {
+			return err
+		}
+		sp, err := s.get(file)
+		if err != nil {
+			return err
+		}
+		file = sp.File
+	}
+
+	p, err := readPprof(file)
 	if err != nil {
 		return err
 	}
-	defer l.Close()
+	return servePprof(args[0], p)
+}
 
-	p, err := readPprof(args[1])
+// servePprof serves p on addr, after printing the address
+// it listens on to stdout.
+func servePprof(addr string, p *Profile) error {
+	l, err := net.Listen("tcp", addr)
 	if err != nil {
 		return err
 	}
+	defer l.Close()
 
 	err = json.NewEncoder(os.Stdout).Encode(map[string]any{
 		"Listen": l.Addr(),
@@ -51,7 +118,41 @@ func runPprofServe(args []string) error {
 		return err
 	}
 
-	return http.Serve(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	return http.Serve(l, pprofHandler(p))
+}
+
+// pprofHandler returns the handler serving p. The root serves the
+// profile itself, normalized if the "normalize" query parameter is set.
+// /labels serves the sample values grouped by the label keys in the
+// "keys" query parameter.
+func pprofHandler(p *Profile) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if normalize, _ := strconv.ParseBool(r.URL.Query().Get("normalize")); normalize {
+			serveJSON(w, (*NormalizedProfile)(p))
+			return
+		}
+		serveJSON(w, (*Profile)(p))
+	})
+	mux.HandleFunc("/labels", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		top := 10
+		if s := q.Get("top"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid top: %v", err), http.StatusBadRequest)
+				return
+			}
+			top = n
+		}
+		g, err := labelGroups(p, q.Get("keys"), q.Get("sample_index"), top)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		serveJSON(w, g)
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
@@ -60,13 +161,29 @@ func runPprofServe(args []string) error {
 			w.WriteHeader(http.StatusOK)
 			return
 		}
+		mux.ServeHTTP(w, r)
+	})
+}
 
-		w.Header().Set("Content-Type", "application/json")
-		err := json.NewEncoder(w).Encode((*Profile)(p))
-		if err != nil {
-			log.Println("Error: ", err)
-		}
-	}))
+func serveJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Println("Error: ", err)
+	}
+}
+
+// labelGroups groups the samples of p by the comma-separated label keys.
+func labelGroups(p *Profile, keys, sampleIdx string, top int) (*LabelGroups, error) {
+	ks := splitKeys(keys)
+	if len(ks) == 0 {
+		return nil, fmt.Errorf("no label keys specified")
+	}
+	si, err := sampleIndex((*profile.Profile)(p), sampleIdx)
+	if err != nil {
+		return nil, err
+	}
+	return groupByLabels((*profile.Profile)(p), ks, si, top), nil
 }
 
 func readPprof(arg string) (*Profile, error) {
@@ -87,21 +204,28 @@ func readPprof(arg string) (*Profile, error) {
 type Profile profile.Profile
 
 func (p *Profile) MarshalJSON() ([]byte, error) {
-	q := struct {
-		SampleType        []*profile.ValueType
-		DefaultSampleType string
-		Sample            []*Sample
-		Mapping           []*profile.Mapping
-		Location          []*Location
-		Function          []*profile.Function
-		Comments          []string
-		DropFrames        string
-		KeepFrames        string
-		TimeNanos         int64
-		DurationNanos     int64
-		PeriodType        *profile.ValueType
-		Period            int64
-	}{
+	return json.Marshal(p.jsonValue())
+}
+
+// profileJSON is the JSON form of a Profile.
+type profileJSON struct {
+	SampleType        []*profile.ValueType
+	DefaultSampleType string
+	Sample            []*Sample
+	Mapping           []*profile.Mapping
+	Location          []*Location
+	Function          []*profile.Function
+	Comments          []string
+	DropFrames        string
+	KeepFrames        string
+	TimeNanos         int64
+	DurationNanos     int64
+	PeriodType        *profile.ValueType
+	Period            int64
+}
+
+func (p *Profile) jsonValue() profileJSON {
+	q := profileJSON{
 		SampleType:        p.SampleType,
 		DefaultSampleType: p.DefaultSampleType,
 		Sample:            make([]*Sample, len(p.Sample)),
@@ -122,19 +246,26 @@ func (p *Profile) MarshalJSON() ([]byte, error) {
 	for i, l := range p.Location {
 		q.Location[i] = (*Location)(l)
 	}
-	return json.Marshal(q)
+	return q
 }
 
 type Sample profile.Sample
 
 func (p *Sample) MarshalJSON() ([]byte, error) {
-	q := struct {
-		Location []uint64
-		Value    []int64
-		Label    map[string][]string
-		NumLabel map[string][]int64
-		NumUnit  map[string][]string
-	}{
+	return json.Marshal(p.jsonValue())
+}
+
+// sampleJSON is the JSON form of a Sample.
+type sampleJSON struct {
+	Location []uint64
+	Value    []int64
+	Label    map[string][]string
+	NumLabel map[string][]int64
+	NumUnit  map[string][]string
+}
+
+func (p *Sample) jsonValue() sampleJSON {
+	q := sampleJSON{
 		Location: make([]uint64, len(p.Location)),
 		Value:    p.Value,
 		Label:    p.Label,
@@ -144,7 +275,7 @@ func (p *Sample) MarshalJSON() ([]byte, error) {
 	for i, l := range p.Location {
 		q.Location[i] = l.ID
 	}
-	return json.Marshal(q)
+	return q
 }
 
 type Location profile.Location
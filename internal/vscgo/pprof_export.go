@@ -0,0 +1,259 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/google/pprof/profile"
+)
+
+// frame is a function on a call stack, as shown by the
+// speedscope and Chrome trace viewers.
+type frame struct {
+	Name string `json:"name"`
+	File string `json:"file,omitempty"`
+	Line int64  `json:"line,omitempty"`
+}
+
+// frameTable assigns indexes to the frames of a profile's call stacks.
+type frameTable struct {
+	frames []frame
+	index  map[frame]int
+}
+
+func (t *frameTable) id(fn *profile.Function) int {
+	f := frame{Name: fn.Name, File: fn.Filename, Line: fn.StartLine}
+	if i, ok := t.index[f]; ok {
+		return i
+	}
+	if t.index == nil {
+		t.index = map[frame]int{}
+	}
+	i := len(t.frames)
+	t.frames = append(t.frames, f)
+	t.index[f] = i
+	return i
+}
+
+// stack returns the frame indexes of the sample's call stack, from the
+// root to the leaf. Inlined calls are expanded into separate frames.
+func (t *frameTable) stack(s *profile.Sample) []int {
+	var st []int
+	for i := len(s.Location) - 1; i >= 0; i-- {
+		lines := s.Location[i].Line
+		for j := len(lines) - 1; j >= 0; j-- {
+			if lines[j].Function != nil {
+				st = append(st, t.id(lines[j].Function))
+			}
+		}
+	}
+	return st
+}
+
+// speedscopeFile is the speedscope file format.
+// See https://www.speedscope.app/file-format-schema.json.
+type speedscopeFile struct {
+	Schema             string              `json:"$schema"`
+	Shared             speedscopeShared    `json:"shared"`
+	Profiles           []speedscopeProfile `json:"profiles"`
+	Name               string              `json:"name,omitempty"`
+	ActiveProfileIndex int                 `json:"activeProfileIndex"`
+	Exporter           string              `json:"exporter"`
+}
+
+type speedscopeShared struct {
+	Frames []frame `json:"frames"`
+}
+
+type speedscopeProfile struct {
+	Type       string  `json:"type"`
+	Name       string  `json:"name"`
+	Unit       string  `json:"unit"`
+	StartValue int64   `json:"startValue"`
+	EndValue   int64   `json:"endValue"`
+	Samples    [][]int `json:"samples"`
+	Weights    []int64 `json:"weights"`
+}
+
+// toSpeedscope converts p to the speedscope file format, with one
+// sampled profile per sample type. The profile of the sample type at
+// index si is the one initially shown.
+func toSpeedscope(p *profile.Profile, name string, si int) *speedscopeFile {
+	var t frameTable
+	stacks := make([][]int, len(p.Sample))
+	for i, s := range p.Sample {
+		stacks[i] = t.stack(s)
+	}
+	f := &speedscopeFile{
+		Schema:             "https://www.speedscope.app/file-format-schema.json",
+		Name:               name,
+		ActiveProfileIndex: si,
+		Exporter:           "vscgo",
+	}
+	for i, st := range p.SampleType {
+		sp := speedscopeProfile{
+			Type:    "sampled",
+			Name:    st.Type,
+			Unit:    speedscopeUnit(st.Unit),
+			Samples: [][]int{},
+			Weights: []int64{},
+		}
+		for j, s := range p.Sample {
+			if s.Value[i] == 0 {
+				continue
+			}
+			sp.Samples = append(sp.Samples, stacks[j])
+			sp.Weights = append(sp.Weights, s.Value[i])
+			sp.EndValue += s.Value[i]
+		}
+		f.Profiles = append(f.Profiles, sp)
+	}
+	f.Shared.Frames = t.frames
+	if f.Shared.Frames == nil {
+		f.Shared.Frames = []frame{}
+	}
+	return f
+}
+
+// speedscopeUnit maps a pprof unit to a speedscope value unit.
+func speedscopeUnit(unit string) string {
+	switch unit {
+	case "nanoseconds", "microseconds", "milliseconds", "seconds", "bytes":
+		return unit
+	}
+	return "none"
+}
+
+// chromeTrace is the JSON object form of the Chrome Trace Event format.
+// See https://docs.google.com/document/d/1CvAClvFfyA5R-PhYUmn5OOQtYMH4h6I0nSsKchNAySU.
+type chromeTrace struct {
+	TraceEvents     []chromeEvent     `json:"traceEvents"`
+	DisplayTimeUnit string            `json:"displayTimeUnit"`
+	OtherData       map[string]string `json:"otherData,omitempty"`
+}
+
+type chromeEvent struct {
+	Name string         `json:"name"`
+	Cat  string         `json:"cat,omitempty"`
+	Ph   string         `json:"ph"`
+	Ts   float64        `json:"ts"`
+	Dur  float64        `json:"dur"`
+	Pid  int            `json:"pid"`
+	Tid  int            `json:"tid"`
+	Args map[string]any `json:"args,omitempty"`
+}
+
+// toChromeTrace converts the values of the sample type at index si to
+// complete ("X") trace events. Samples have no timestamps, so they are
+// laid out one after another, sorted by stack like a flame graph, and
+// adjacent samples sharing a stack prefix share the events for it.
+// Time values are converted to microseconds; values of other units are
+// used as is.
+func toChromeTrace(p *profile.Profile, name string, si int) (*chromeTrace, error) {
+	if si < 0 || si >= len(p.SampleType) {
+		return nil, fmt.Errorf("sample index %d out of range", si)
+	}
+	st := p.SampleType[si]
+	scale := 1.0
+	switch st.Unit {
+	case "nanoseconds":
+		scale = 1e-3
+	case "microseconds":
+		scale = 1
+	case "milliseconds":
+		scale = 1e3
+	case "seconds":
+		scale = 1e6
+	}
+
+	var t frameTable
+	type weighted struct {
+		stack []int
+		value int64
+	}
+	var samples []weighted
+	for _, s := range p.Sample {
+		if v := s.Value[si]; v != 0 {
+			samples = append(samples, weighted{t.stack(s), v})
+		}
+	}
+	slices.SortStableFunc(samples, func(a, b weighted) int {
+		return compareStacks(t.frames, a.stack, b.stack)
+	})
+
+	tr := &chromeTrace{
+		TraceEvents:     []chromeEvent{},
+		DisplayTimeUnit: "ms",
+		OtherData: map[string]string{
+			"name":       name,
+			"sampleType": st.Type,
+			"unit":       st.Unit,
+		},
+	}
+	type open struct {
+		frame int
+		start float64
+	}
+	var opened []open
+	closeTo := func(depth int, now float64) {
+		for len(opened) > depth {
+			o := opened[len(opened)-1]
+			opened = opened[:len(opened)-1]
+			f := t.frames[o.frame]
+			ev := chromeEvent{
+				Name: f.Name,
+				Cat:  st.Type,
+				Ph:   "X",
+				Ts:   o.start,
+				Dur:  now - o.start,
+				Pid:  1,
+				Tid:  1,
+			}
+			if f.File != "" {
+				ev.Args = map[string]any{"file": f.File, "line": f.Line}
+			}
+			tr.TraceEvents = append(tr.TraceEvents, ev)
+		}
+	}
+	now := 0.0
+	for _, s := range samples {
+		common := 0
+		for common < len(opened) && common < len(s.stack) && opened[common].frame == s.stack[common] {
+			common++
+		}
+		closeTo(common, now)
+		for _, f := range s.stack[common:] {
+			opened = append(opened, open{f, now})
+		}
+		now += float64(s.value) * scale
+	}
+	closeTo(0, now)
+	// Callers are closed after their callees; order events by start
+	// time with callers first, as viewers expect.
+	slices.SortStableFunc(tr.TraceEvents, func(a, b chromeEvent) int {
+		if c := cmp.Compare(a.Ts, b.Ts); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.Dur, a.Dur)
+	})
+	return tr, nil
+}
+
+// compareStacks orders stacks by the names of their frames, root first.
+func compareStacks(frames []frame, a, b []int) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		if c := cmp.Compare(frames[a[i]].Name, frames[b[i]].Name); c != 0 {
+			return c
+		}
+		return cmp.Compare(a[i], b[i])
+	}
+	return cmp.Compare(len(a), len(b))
+}
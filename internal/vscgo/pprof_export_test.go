@@ -0,0 +1,69 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestToSpeedscope(t *testing.T) {
+	f := toSpeedscope(testProfile(), "cpu.pprof", 1)
+
+	var names []string
+	for _, fr := range f.Shared.Frames {
+		names = append(names, fr.Name)
+	}
+	if want := []string{"main.main", "main.handle", "main.parse", "main.render"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("frames = %v, want %v", names, want)
+	}
+	if len(f.Profiles) != 2 {
+		t.Fatalf("got %d profiles, want 2", len(f.Profiles))
+	}
+	cpu := f.Profiles[1]
+	if cpu.Name != "cpu" || cpu.Unit != "nanoseconds" || cpu.EndValue != 110000000 {
+		t.Errorf("cpu profile = %s %s %d, want cpu nanoseconds 110000000", cpu.Name, cpu.Unit, cpu.EndValue)
+	}
+	wantSamples := [][]int{{0, 1, 2}, {0, 1, 3}, {0, 1, 3}, {0}}
+	if !reflect.DeepEqual(cpu.Samples, wantSamples) {
+		t.Errorf("samples = %v, want %v", cpu.Samples, wantSamples)
+	}
+	if got := f.Profiles[0].Unit; got != "none" {
+		t.Errorf("unit of count samples = %q, want none", got)
+	}
+}
+
+func TestToChromeTrace(t *testing.T) {
+	tr, err := toChromeTrace(testProfile(), "cpu.pprof", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type span struct {
+		Name    string
+		Ts, Dur float64
+	}
+	var got []span
+	for _, ev := range tr.TraceEvents {
+		if ev.Ph != "X" {
+			t.Errorf("event %+v: phase %q, want X", ev, ev.Ph)
+		}
+		got = append(got, span{ev.Name, ev.Ts, ev.Dur})
+	}
+	// Samples are sorted by stack and laid out in microseconds:
+	// main (20ms), main/handle/parse (30ms), main/handle/render (60ms).
+	want := []span{
+		{"main.main", 0, 110000},
+		{"main.handle", 20000, 90000},
+		{"main.parse", 20000, 30000},
+		{"main.render", 50000, 60000},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("trace events = %v, want %v", got, want)
+	}
+
+	if _, err := toChromeTrace(testProfile(), "cpu.pprof", 2); err == nil {
+		t.Errorf("toChromeTrace with out of range sample index succeeded")
+	}
+}
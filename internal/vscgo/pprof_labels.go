@@ -0,0 +1,223 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/pprof/profile"
+)
+
+// LabelGroups is the breakdown of a profile's sample values by the values
+// of a set of pprof label keys.
+type LabelGroups struct {
+	Keys        []string
+	SampleType  []*profile.ValueType
+	SampleIndex int // sample type used to rank groups and functions
+	Groups      []*LabelGroup
+}
+
+// LabelGroup holds the aggregated values of all samples that share the same
+// values for the requested label keys.
+type LabelGroup struct {
+	// Labels maps each requested key to the sample's value for it.
+	// Samples without the key have an empty value. Multiple values
+	// for one key are joined with commas.
+	Labels  map[string]string
+	Samples int
+	Values  []int64 // one per sample type
+	Top     []*FunctionStat
+}
+
+// FunctionStat is the flat and cumulative value of a function for
+// the selected sample type.
+type FunctionStat struct {
+	Name string
+	File string
+	Flat int64
+	Cum  int64
+}
+
+// sampleIndex returns the index of the sample type named by s,
+// which is either a sample type name or a decimal index.
+// If s is empty, the profile's default sample type is used,
+// and, lacking one, the last sample type, as go tool pprof does.
+func sampleIndex(p *profile.Profile, s string) (int, error) {
+	if len(p.SampleType) == 0 {
+		return 0, fmt.Errorf("profile has no sample types")
+	}
+	if s == "" {
+		s = p.DefaultSampleType
+		if s == "" {
+			return len(p.SampleType) - 1, nil
+		}
+	}
+	if i, err := strconv.Atoi(s); err == nil {
+		if i < 0 || i >= len(p.SampleType) {
+			return 0, fmt.Errorf("sample_index %d out of range [0..%d]", i, len(p.SampleType)-1)
+		}
+		return i, nil
+	}
+	for i, t := range p.SampleType {
+		if t.Type == s {
+			return i, nil
+		}
+	}
+	var names []string
+	for _, t := range p.SampleType {
+		names = append(names, t.Type)
+	}
+	return 0, fmt.Errorf("sample type %q not found, want one of %s", s, strings.Join(names, ", "))
+}
+
+// groupByLabels aggregates the samples of p by the values of the given
+// label keys. Groups are sorted by decreasing value of the sample type
+// at index si, and each group reports at most top functions.
+func groupByLabels(p *profile.Profile, keys []string, si, top int) *LabelGroups {
+	type group struct {
+		*LabelGroup
+		funcs map[funcKey]*FunctionStat
+	}
+	groups := map[string]*group{}
+	var order []*group
+	for _, s := range p.Sample {
+		labels := sampleLabels(s, keys)
+		id := labelsKey(labels, keys)
+		g := groups[id]
+		if g == nil {
+			g = &group{
+				LabelGroup: &LabelGroup{
+					Labels: labels,
+					Values: make([]int64, len(p.SampleType)),
+				},
+				funcs: map[funcKey]*FunctionStat{},
+			}
+			groups[id] = g
+			order = append(order, g)
+		}
+		g.Samples++
+		for i, v := range s.Value {
+			g.Values[i] += v
+		}
+		addFunctionStats(g.funcs, s, s.Value[si])
+	}
+
+	res := &LabelGroups{
+		Keys:        keys,
+		SampleType:  p.SampleType,
+		SampleIndex: si,
+		Groups:      make([]*LabelGroup, len(order)),
+	}
+	for i, g := range order {
+		g.Top = topFunctions(g.funcs, top)
+		res.Groups[i] = g.LabelGroup
+	}
+	slices.SortStableFunc(res.Groups, func(a, b *LabelGroup) int {
+		return cmp.Compare(b.Values[si], a.Values[si])
+	})
+	return res
+}
+
+// sampleLabels returns the values of the keys in the sample's string
+// or numeric labels.
+func sampleLabels(s *profile.Sample, keys []string) map[string]string {
+	labels := make(map[string]string, len(keys))
+	for _, k := range keys {
+		if vs, ok := s.Label[k]; ok {
+			labels[k] = strings.Join(vs, ",")
+			continue
+		}
+		if ns, ok := s.NumLabel[k]; ok {
+			vs := make([]string, len(ns))
+			for i, n := range ns {
+				vs[i] = strconv.FormatInt(n, 10)
+			}
+			labels[k] = strings.Join(vs, ",")
+			continue
+		}
+		labels[k] = ""
+	}
+	return labels
+}
+
+func labelsKey(labels map[string]string, keys []string) string {
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(strconv.Quote(labels[k]))
+		b.WriteByte(0)
+	}
+	return b.String()
+}
+
+type funcKey struct {
+	name, file string
+}
+
+// addFunctionStats adds v to the flat value of the sample's leaf function
+// and to the cumulative value of every function on its stack. Functions
+// appearing more than once on the stack, as with recursion, are counted
+// once.
+func addFunctionStats(funcs map[funcKey]*FunctionStat, s *profile.Sample, v int64) {
+	seen := map[funcKey]bool{}
+	leaf := true
+	for _, loc := range s.Location {
+		for _, line := range loc.Line {
+			if line.Function == nil {
+				continue
+			}
+			k := funcKey{line.Function.Name, line.Function.Filename}
+			st := funcs[k]
+			if st == nil {
+				st = &FunctionStat{Name: k.name, File: k.file}
+				funcs[k] = st
+			}
+			if leaf {
+				st.Flat += v
+				leaf = false
+			}
+			if !seen[k] {
+				st.Cum += v
+				seen[k] = true
+			}
+		}
+	}
+}
+
+// topFunctions returns up to n functions with the largest flat values.
+// Ties are broken by cumulative value and then by name.
+func topFunctions(funcs map[funcKey]*FunctionStat, n int) []*FunctionStat {
+	all := make([]*FunctionStat, 0, len(funcs))
+	for _, st := range funcs {
+		all = append(all, st)
+	}
+	slices.SortFunc(all, func(a, b *FunctionStat) int {
+		if c := cmp.Compare(b.Flat, a.Flat); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.Cum, a.Cum); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+	if n >= 0 && len(all) > n {
+		all = all[:n]
+	}
+	return all
+}
+
+// splitKeys splits a comma-separated list of label keys.
+func splitKeys(s string) []string {
+	var keys []string
+	for k := range strings.SplitSeq(s, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,172 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/google/pprof/profile"
+)
+
+// testProfile returns a small CPU profile with the call stacks
+// main -> handle -> parse and main -> handle -> render, whose
+// samples carry a "request" label.
+func testProfile() *profile.Profile {
+	fns := []*profile.Function{
+		{ID: 1, Name: "main.main", Filename: "main.go", StartLine: 10},
+		{ID: 2, Name: "main.handle", Filename: "main.go", StartLine: 20},
+		{ID: 3, Name: "main.parse", Filename: "parse.go", StartLine: 5},
+		{ID: 4, Name: "main.render", Filename: "render.go", StartLine: 7},
+	}
+	m := &profile.Mapping{ID: 1, File: "main"}
+	locs := make([]*profile.Location, len(fns))
+	for i, fn := range fns {
+		locs[i] = &profile.Location{
+			ID:      uint64(i + 1),
+			Mapping: m,
+			Address: uint64(0x1000 * (i + 1)),
+			Line:    []profile.Line{{Function: fn, Line: fn.StartLine + 1}},
+		}
+	}
+	stack := func(ids ...int) []*profile.Location {
+		var s []*profile.Location
+		for _, id := range ids {
+			s = append(s, locs[id-1])
+		}
+		return s
+	}
+	return &profile.Profile{
+		SampleType: []*profile.ValueType{
+			{Type: "samples", Unit: "count"},
+			{Type: "cpu", Unit: "nanoseconds"},
+		},
+		PeriodType: &profile.ValueType{Type: "cpu", Unit: "nanoseconds"},
+		Period:     10000000,
+		Sample: []*profile.Sample{
+			{Location: stack(3, 2, 1), Value: []int64{3, 30000000}, Label: map[string][]string{"request": {"api"}}},
+			{Location: stack(4, 2, 1), Value: []int64{1, 10000000}, Label: map[string][]string{"request": {"api"}}},
+			{Location: stack(4, 2, 1), Value: []int64{5, 50000000}, Label: map[string][]string{"request": {"page"}}},
+			{Location: stack(1), Value: []int64{2, 20000000}},
+		},
+		Mapping:  []*profile.Mapping{m},
+		Location: locs,
+		Function: fns,
+	}
+}
+
+func TestGroupByLabels(t *testing.T) {
+	p := testProfile()
+	si, err := sampleIndex(p, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if si != 1 {
+		t.Fatalf("sampleIndex(p, \"\") = %d, want 1", si)
+	}
+	g := groupByLabels(p, []string{"request"}, si, 2)
+
+	type group struct {
+		Labels  map[string]string
+		Samples int
+		Values  []int64
+		Top     []FunctionStat
+	}
+	var got []group
+	for _, gg := range g.Groups {
+		var top []FunctionStat
+		for _, f := range gg.Top {
+			top = append(top, *f)
+		}
+		got = append(got, group{gg.Labels, gg.Samples, gg.Values, top})
+	}
+	want := []group{
+		{
+			Labels:  map[string]string{"request": "page"},
+			Samples: 1,
+			Values:  []int64{5, 50000000},
+			Top: []FunctionStat{
+				{Name: "main.render", File: "render.go", Flat: 50000000, Cum: 50000000},
+				{Name: "main.handle", File: "main.go", Flat: 0, Cum: 50000000},
+			},
+		},
+		{
+			Labels:  map[string]string{"request": "api"},
+			Samples: 2,
+			Values:  []int64{4, 40000000},
+			Top: []FunctionStat{
+				{Name: "main.parse", File: "parse.go", Flat: 30000000, Cum: 30000000},
+				{Name: "main.render", File: "render.go", Flat: 10000000, Cum: 10000000},
+			},
+		},
+		{
+			Labels:  map[string]string{"request": ""},
+			Samples: 1,
+			Values:  []int64{2, 20000000},
+			Top: []FunctionStat{
+				{Name: "main.main", File: "main.go", Flat: 20000000, Cum: 20000000},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("groupByLabels = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestSampleIndex(t *testing.T) {
+	p := testProfile()
+	for _, tc := range []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{in: "samples", want: 0},
+		{in: "0", want: 0},
+		{in: "cpu", want: 1},
+		{in: "2", wantErr: true},
+		{in: "alloc_space", wantErr: true},
+	} {
+		got, err := sampleIndex(p, tc.in)
+		if (err != nil) != tc.wantErr || (err == nil && got != tc.want) {
+			t.Errorf("sampleIndex(%q) = %v, %v, want %v (wantErr=%v)", tc.in, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
+
+func TestPprofHandlerLabels(t *testing.T) {
+	srv := httptest.NewServer(pprofHandler((*Profile)(testProfile())))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/labels?keys=request&sample_index=samples&top=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /labels: %v", resp.Status)
+	}
+	var g LabelGroups
+	if err := json.NewDecoder(resp.Body).Decode(&g); err != nil {
+		t.Fatal(err)
+	}
+	if g.SampleIndex != 0 || len(g.Groups) != 3 {
+		t.Fatalf("GET /labels = %+v, want 3 groups for sample index 0", g)
+	}
+	if got := g.Groups[0]; got.Labels["request"] != "page" || len(got.Top) != 1 {
+		t.Errorf("first group = %+v, want request=page with one top function", got)
+	}
+
+	resp, err = http.Get(srv.URL + "/labels")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("GET /labels without keys: %v, want %v", resp.Status, http.StatusBadRequest)
+	}
+}
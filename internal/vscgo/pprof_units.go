@@ -0,0 +1,185 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/pprof/profile"
+)
+
+// NormalizedProfile is a Profile whose JSON form additionally carries
+// sample values scaled to readable units and derived sample types,
+// such as the average allocation size of heap profiles.
+// Its DefaultSampleType is always set, chosen as go tool pprof does.
+type NormalizedProfile Profile
+
+// SampleUnit describes how the values of a sample type are scaled:
+// the scaled value is the raw value multiplied by Factor.
+type SampleUnit struct {
+	Type       string
+	Unit       string
+	ScaledUnit string
+	Factor     float64
+}
+
+// DerivedSampleType is a sample type computed for each sample as the
+// ratio of the values of two other sample types.
+type DerivedSampleType struct {
+	SampleUnit
+	Numerator   int // index into SampleType
+	Denominator int // index into SampleType
+}
+
+func (p *NormalizedProfile) MarshalJSON() ([]byte, error) {
+	pp := (*profile.Profile)(p)
+	q := struct {
+		profileJSON
+		Sample            []*normalizedSample
+		SampleUnit        []*SampleUnit
+		DerivedSampleType []*DerivedSampleType
+	}{
+		profileJSON: (*Profile)(p).jsonValue(),
+		Sample:      make([]*normalizedSample, len(p.Sample)),
+		SampleUnit:  make([]*SampleUnit, len(p.SampleType)),
+	}
+	if si, err := sampleIndex(pp, ""); err == nil {
+		q.DefaultSampleType = p.SampleType[si].Type
+	}
+
+	for i, st := range p.SampleType {
+		var largest int64
+		for _, s := range p.Sample {
+			largest = max(largest, s.Value[i], -s.Value[i])
+		}
+		q.SampleUnit[i] = scaledUnit(st.Type, st.Unit, float64(largest))
+	}
+	q.DerivedSampleType = derivedSampleTypes(pp)
+	ratios := make([][]float64, len(p.Sample))
+	for j, s := range p.Sample {
+		ratios[j] = make([]float64, len(q.DerivedSampleType))
+		for i, d := range q.DerivedSampleType {
+			if den := s.Value[d.Denominator]; den != 0 {
+				ratios[j][i] = float64(s.Value[d.Numerator]) / float64(den)
+			}
+		}
+	}
+	for i, d := range q.DerivedSampleType {
+		var largest float64
+		for _, r := range ratios {
+			largest = max(largest, r[i], -r[i])
+		}
+		d.SampleUnit = *scaledUnit(d.Type, d.Unit, largest)
+	}
+
+	for j, s := range p.Sample {
+		ns := &normalizedSample{
+			sampleJSON: (*Sample)(s).jsonValue(),
+			Scaled:     make([]float64, len(s.Value)),
+			Derived:    make([]float64, len(q.DerivedSampleType)),
+		}
+		for i, v := range s.Value {
+			ns.Scaled[i] = float64(v) * q.SampleUnit[i].Factor
+		}
+		for i, d := range q.DerivedSampleType {
+			ns.Derived[i] = ratios[j][i] * d.Factor
+		}
+		q.Sample[j] = ns
+	}
+	return json.Marshal(q)
+}
+
+// normalizedSample is the JSON form of a sample of a NormalizedProfile.
+type normalizedSample struct {
+	sampleJSON
+	Scaled  []float64 // Value scaled by the profile's SampleUnit
+	Derived []float64 // one per DerivedSampleType, scaled
+}
+
+// derivedRatios lists the sample types derived from pairs of
+// sample types found in Go runtime profiles.
+var derivedRatios = []struct {
+	typ, numerator, denominator string
+}{
+	{"alloc_avg_size", "alloc_space", "alloc_objects"},
+	{"inuse_avg_size", "inuse_space", "inuse_objects"},
+	{"avg_delay", "delay", "contentions"},
+}
+
+func derivedSampleTypes(p *profile.Profile) []*DerivedSampleType {
+	index := func(typ string) int {
+		return slices.IndexFunc(p.SampleType, func(t *profile.ValueType) bool { return t.Type == typ })
+	}
+	res := []*DerivedSampleType{}
+	for _, r := range derivedRatios {
+		n, d := index(r.numerator), index(r.denominator)
+		if n < 0 || d < 0 {
+			continue
+		}
+		res = append(res, &DerivedSampleType{
+			SampleUnit:  SampleUnit{Type: r.typ, Unit: p.SampleType[n].Unit},
+			Numerator:   n,
+			Denominator: d,
+		})
+	}
+	return res
+}
+
+// A measurementUnit is a unit and its size in terms of the base unit of
+// its kind. The tables below mirror the memory and time units of pprof's
+// internal measurement package, so scaled values read like go tool pprof's.
+type measurementUnit struct {
+	name    string
+	aliases []string
+	factor  float64
+}
+
+var measurementUnits = [][]measurementUnit{{
+	{"B", []string{"b", "byte"}, 1},
+	{"kB", []string{"kb", "kbyte", "kilobyte"}, float64(1 << 10)},
+	{"MB", []string{"mb", "mbyte", "megabyte"}, float64(1 << 20)},
+	{"GB", []string{"gb", "gbyte", "gigabyte"}, float64(1 << 30)},
+	{"TB", []string{"tb", "tbyte", "terabyte"}, float64(1 << 40)},
+	{"PB", []string{"pb", "pbyte", "petabyte"}, float64(1 << 50)},
+}, {
+	{"ns", []string{"ns", "nanosecond"}, float64(time.Nanosecond)},
+	{"us", []string{"μs", "us", "microsecond"}, float64(time.Microsecond)},
+	{"ms", []string{"ms", "millisecond"}, float64(time.Millisecond)},
+	{"s", []string{"s", "sec", "second"}, float64(time.Second)},
+	{"hrs", []string{"hour", "hr"}, float64(time.Hour)},
+}}
+
+// scaledUnit returns the unit that values of the given unit, up to largest,
+// are best shown in: the largest unit of the same kind in which largest is
+// at least 1. Values of units pprof does not know, like counts, are not scaled.
+func scaledUnit(typ, unit string, largest float64) *SampleUnit {
+	u := &SampleUnit{Type: typ, Unit: unit, ScaledUnit: unit, Factor: 1}
+	name := strings.ToLower(unit)
+	if len(name) > 2 {
+		name = strings.TrimSuffix(name, "s")
+	}
+	for _, units := range measurementUnits {
+		i := slices.IndexFunc(units, func(m measurementUnit) bool {
+			return slices.Contains(m.aliases, name)
+		})
+		if i < 0 {
+			continue
+		}
+		from := units[i]
+		to := from
+		for _, m := range units {
+			if largest*from.factor/m.factor >= 1 && m.factor > to.factor {
+				to = m
+			}
+		}
+		u.ScaledUnit = to.name
+		u.Factor = from.factor / to.factor
+		return u
+	}
+	return u
+}
@@ -0,0 +1,94 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/google/pprof/profile"
+)
+
+func TestScaledUnit(t *testing.T) {
+	for _, tc := range []struct {
+		unit    string
+		largest float64
+		want    string
+		factor  float64
+	}{
+		{"nanoseconds", 0, "ns", 1},
+		{"nanoseconds", 999, "ns", 1},
+		{"nanoseconds", 50e6, "ms", 1e-6},
+		{"nanoseconds", 3e9, "s", 1e-9},
+		{"bytes", 4096, "kB", 1.0 / 1024},
+		{"bytes", 3 << 20, "MB", 1.0 / (1 << 20)},
+		{"count", 1e9, "count", 1},
+	} {
+		u := scaledUnit("t", tc.unit, tc.largest)
+		if u.ScaledUnit != tc.want || u.Factor != tc.factor {
+			t.Errorf("scaledUnit(%q, %v) = %s (factor %v), want %s (factor %v)", tc.unit, tc.largest, u.ScaledUnit, u.Factor, tc.want, tc.factor)
+		}
+	}
+}
+
+func TestNormalizedProfile(t *testing.T) {
+	fn := &profile.Function{ID: 1, Name: "main.alloc", Filename: "main.go"}
+	loc := &profile.Location{ID: 1, Mapping: &profile.Mapping{ID: 1}, Line: []profile.Line{{Function: fn, Line: 3}}}
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{
+			{Type: "alloc_objects", Unit: "count"},
+			{Type: "alloc_space", Unit: "bytes"},
+			{Type: "inuse_objects", Unit: "count"},
+			{Type: "inuse_space", Unit: "bytes"},
+		},
+		Sample: []*profile.Sample{
+			{Location: []*profile.Location{loc}, Value: []int64{4, 8192, 0, 0}},
+			{Location: []*profile.Location{loc}, Value: []int64{1, 1024, 1, 1024}},
+		},
+		Location: []*profile.Location{loc},
+		Function: []*profile.Function{fn},
+	}
+	data, err := json.Marshal((*NormalizedProfile)(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		DefaultSampleType string
+		SampleUnit        []SampleUnit
+		DerivedSampleType []DerivedSampleType
+		Sample            []struct {
+			Value   []int64
+			Scaled  []float64
+			Derived []float64
+		}
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.DefaultSampleType != "inuse_space" {
+		t.Errorf("DefaultSampleType = %q, want inuse_space", got.DefaultSampleType)
+	}
+	if u := got.SampleUnit[1]; u.ScaledUnit != "kB" {
+		t.Errorf("alloc_space scaled unit = %q, want kB", u.ScaledUnit)
+	}
+	var derived []string
+	for _, d := range got.DerivedSampleType {
+		derived = append(derived, d.Type+"/"+d.ScaledUnit)
+	}
+	if want := []string{"alloc_avg_size/kB", "inuse_avg_size/kB"}; !reflect.DeepEqual(derived, want) {
+		t.Errorf("derived sample types = %v, want %v", derived, want)
+	}
+	s := got.Sample[0]
+	if want := []float64{4, 8, 0, 0}; !reflect.DeepEqual(s.Scaled, want) {
+		t.Errorf("scaled values = %v, want %v", s.Scaled, want)
+	}
+	if want := []float64{2, 0}; !reflect.DeepEqual(s.Derived, want) {
+		t.Errorf("derived values = %v, want %v", s.Derived, want)
+	}
+	if want := []int64{4, 8192, 0, 0}; !reflect.DeepEqual(s.Value, want) {
+		t.Errorf("raw values = %v, want %v", s.Value, want)
+	}
+}
@@ -0,0 +1,416 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/pprof/profile"
+)
+
+var (
+	profilesFlags     = flag.NewFlagSet("profiles", flag.ExitOnError)
+	profilesDir       = profilesFlags.String("dir", "", "profile store directory (default: vscode-go/profiles in the user cache directory)")
+	profilesTest      = profilesFlags.String("test", "", "test name recorded by add, and used to filter list and prune")
+	profilesPkg       = profilesFlags.String("pkg", "", "package path recorded by add, and used to filter list and prune")
+	profilesKind      = profilesFlags.String("kind", "", "profile kind (cpu, heap, ...) recorded by add instead of the one inferred from the profile, and used to filter list and prune")
+	profilesCommit    = profilesFlags.String("commit", "", "commit recorded by add (default: HEAD of the git repository in the current directory, if any)")
+	profilesKeep      = profilesFlags.Int("keep", 0, "for prune, the number of most recent profiles to keep for each kind, package and test (0 keeps all)")
+	profilesOlderThan = profilesFlags.Duration("older-than", 0, "for prune, remove profiles older than this")
+	profilesIndex     = profilesFlags.String("sample_index", "", "for compare, sample type, by name or index, to compare (default: the profile's default sample type)")
+	profilesTop       = profilesFlags.Int("top", 20, "for compare, number of functions with the largest changes to report")
+)
+
+// StoredProfile is the metadata of a profile in the profile store.
+type StoredProfile struct {
+	ID      string
+	Kind    string
+	Test    string            `json:",omitempty"`
+	Package string            `json:",omitempty"`
+	Commit  string            `json:",omitempty"`
+	Time    time.Time         // when the profile was stored
+	Extra   map[string]string `json:",omitempty"`
+	File    string            // path of the profile in the store
+}
+
+// profileStore keeps profiles and their metadata in a directory.
+// The profile with ID id is stored in id.pb.gz, and its metadata,
+// a JSON-encoded StoredProfile, in id.json.
+type profileStore struct {
+	dir string
+}
+
+func defaultProfileStoreDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "vscode-go", "profiles"), nil
+}
+
+func openProfileStore(dir string) (*profileStore, error) {
+	if dir == "" {
+		d, err := defaultProfileStoreDir()
+		if err != nil {
+			return nil, err
+		}
+		dir = d
+	}
+	return &profileStore{dir: dir}, nil
+}
+
+// add stores the profile in data with the given metadata. The ID, Time and
+// File of meta are set by add, and Kind is inferred from the profile if empty.
+func (s *profileStore) add(data []byte, meta StoredProfile, now time.Time) (*StoredProfile, error) {
+	p, err := profile.ParseData(data)
+	if err != nil {
+		return nil, err
+	}
+	if meta.Kind == "" {
+		meta.Kind = profileKind(p)
+	}
+	sum := sha256.Sum256(data)
+	meta.Time = now.UTC()
+	meta.ID = meta.Time.Format("20060102T150405Z") + "-" + hex.EncodeToString(sum[:4])
+	meta.File = filepath.Join(s.dir, meta.ID+".pb.gz")
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, err
+	}
+	// Store the profile in the compressed form pprof writes,
+	// whatever form it was given in.
+	var buf bytes.Buffer
+	if err := p.Write(&buf); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(meta.File, buf.Bytes(), 0644); err != nil {
+		return nil, err
+	}
+	js, err := json.MarshalIndent(meta, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(s.dir, meta.ID+".json"), js, 0644); err != nil {
+		os.Remove(meta.File)
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// list returns the stored profiles, most recent first.
+func (s *profileStore) list() ([]*StoredProfile, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	res := []*StoredProfile{}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		var sp StoredProfile
+		if err := json.Unmarshal(data, &sp); err != nil {
+			return nil, fmt.Errorf("%s: %v", f, err)
+		}
+		sp.File = filepath.Join(s.dir, sp.ID+".pb.gz")
+		res = append(res, &sp)
+	}
+	slices.SortFunc(res, func(a, b *StoredProfile) int {
+		if c := b.Time.Compare(a.Time); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.ID, a.ID)
+	})
+	return res, nil
+}
+
+// get returns the metadata of the profile with the given ID.
+func (s *profileStore) get(id string) (*StoredProfile, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return nil, fmt.Errorf("invalid profile ID %q", id)
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, id+".json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("profile %q not found in %s", id, s.dir)
+	} else if err != nil {
+		return nil, err
+	}
+	var sp StoredProfile
+	if err := json.Unmarshal(data, &sp); err != nil {
+		return nil, fmt.Errorf("profile %q: %v", id, err)
+	}
+	sp.File = filepath.Join(s.dir, sp.ID+".pb.gz")
+	return &sp, nil
+}
+
+// remove deletes the profile and its metadata from the store.
+func (s *profileStore) remove(sp *StoredProfile) error {
+	err1 := os.Remove(filepath.Join(s.dir, sp.ID+".json"))
+	err2 := os.Remove(filepath.Join(s.dir, sp.ID+".pb.gz"))
+	if errors.Is(err2, fs.ErrNotExist) {
+		err2 = nil
+	}
+	return errors.Join(err1, err2)
+}
+
+// prune removes the profiles matching filter that are older than maxAge,
+// if maxAge is positive, or that are not among the keep most recent
+// profiles of the same kind, package and test, if keep is positive.
+// It returns the removed profiles.
+func (s *profileStore) prune(filter func(*StoredProfile) bool, keep int, maxAge time.Duration, now time.Time) ([]*StoredProfile, error) {
+	all, err := s.list()
+	if err != nil {
+		return nil, err
+	}
+	removed := []*StoredProfile{}
+	seen := map[[3]string]int{}
+	for _, sp := range all {
+		if !filter(sp) {
+			continue
+		}
+		k := [3]string{sp.Kind, sp.Package, sp.Test}
+		seen[k]++
+		if (keep > 0 && seen[k] > keep) || (maxAge > 0 && now.Sub(sp.Time) > maxAge) {
+			if err := s.remove(sp); err != nil {
+				return removed, err
+			}
+			removed = append(removed, sp)
+		}
+	}
+	return removed, nil
+}
+
+// profileKind infers the kind of a profile written by the Go runtime
+// from its sample types.
+func profileKind(p *profile.Profile) string {
+	has := func(typ string) bool {
+		return slices.ContainsFunc(p.SampleType, func(t *profile.ValueType) bool { return t.Type == typ })
+	}
+	switch {
+	case has("cpu"):
+		return "cpu"
+	case has("inuse_space"), has("alloc_space"):
+		return "heap"
+	case has("delay"), has("contentions"):
+		return "contention"
+	case has("goroutine"):
+		return "goroutine"
+	case has("threadcreate"):
+		return "threadcreate"
+	}
+	if p.PeriodType != nil && p.PeriodType.Type != "" {
+		return p.PeriodType.Type
+	}
+	return "unknown"
+}
+
+// ProfileComparison is the difference between two stored profiles
+// in the values of one sample type.
+type ProfileComparison struct {
+	Base, Head *StoredProfile
+	SampleType *profile.ValueType
+	BaseTotal  int64
+	HeadTotal  int64
+	Functions  []*FunctionDelta // largest absolute changes first
+}
+
+// FunctionDelta is the change in a function's flat and cumulative values.
+type FunctionDelta struct {
+	Name     string
+	File     string
+	BaseFlat int64
+	HeadFlat int64
+	BaseCum  int64
+	HeadCum  int64
+}
+
+// compareProfiles compares the values of the sample type named by
+// sampleIdx in base and head, reporting the top functions whose flat
+// values changed most.
+func compareProfiles(base, head *profile.Profile, sampleIdx string, top int) (*ProfileComparison, error) {
+	hi, err := sampleIndex(head, sampleIdx)
+	if err != nil {
+		return nil, fmt.Errorf("head: %v", err)
+	}
+	st := head.SampleType[hi]
+	bi, err := sampleIndex(base, st.Type)
+	if err != nil {
+		return nil, fmt.Errorf("base: %v", err)
+	}
+	if u := base.SampleType[bi].Unit; u != st.Unit {
+		return nil, fmt.Errorf("sample type %s has unit %s in base and %s in head", st.Type, u, st.Unit)
+	}
+
+	funcStats := func(p *profile.Profile, si int) (map[funcKey]*FunctionStat, int64) {
+		funcs := map[funcKey]*FunctionStat{}
+		var total int64
+		for _, s := range p.Sample {
+			addFunctionStats(funcs, s, s.Value[si])
+			total += s.Value[si]
+		}
+		return funcs, total
+	}
+	bf, btotal := funcStats(base, bi)
+	hf, htotal := funcStats(head, hi)
+
+	deltas := map[funcKey]*FunctionDelta{}
+	delta := func(k funcKey) *FunctionDelta {
+		d := deltas[k]
+		if d == nil {
+			d = &FunctionDelta{Name: k.name, File: k.file}
+			deltas[k] = d
+		}
+		return d
+	}
+	for k, f := range bf {
+		d := delta(k)
+		d.BaseFlat, d.BaseCum = f.Flat, f.Cum
+	}
+	for k, f := range hf {
+		d := delta(k)
+		d.HeadFlat, d.HeadCum = f.Flat, f.Cum
+	}
+	c := &ProfileComparison{
+		SampleType: st,
+		BaseTotal:  btotal,
+		HeadTotal:  htotal,
+		Functions:  []*FunctionDelta{},
+	}
+	for _, d := range deltas {
+		if d.BaseFlat != d.HeadFlat || d.BaseCum != d.HeadCum {
+			c.Functions = append(c.Functions, d)
+		}
+	}
+	abs := func(v int64) int64 { return max(v, -v) }
+	slices.SortFunc(c.Functions, func(a, b *FunctionDelta) int {
+		if c := cmp.Compare(abs(b.HeadFlat-b.BaseFlat), abs(a.HeadFlat-a.BaseFlat)); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(abs(b.HeadCum-b.BaseCum), abs(a.HeadCum-a.BaseCum)); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+	if top >= 0 && len(c.Functions) > top {
+		c.Functions = c.Functions[:top]
+	}
+	return c, nil
+}
+
+func runProfiles(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: profiles [flags] add|list|prune|compare ...")
+	}
+	s, err := openProfileStore(*profilesDir)
+	if err != nil {
+		return err
+	}
+	filter := func(sp *StoredProfile) bool {
+		return (*profilesTest == "" || sp.Test == *profilesTest) &&
+			(*profilesPkg == "" || sp.Package == *profilesPkg) &&
+			(*profilesKind == "" || sp.Kind == *profilesKind)
+	}
+	enc := json.NewEncoder(os.Stdout)
+
+	switch cmd, args := args[0], args[1:]; cmd {
+	case "add":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: profiles [flags] add <profile>")
+		}
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		commit := *profilesCommit
+		if commit == "" {
+			commit = gitHead()
+		}
+		sp, err := s.add(data, StoredProfile{
+			Kind:    *profilesKind,
+			Test:    *profilesTest,
+			Package: *profilesPkg,
+			Commit:  commit,
+		}, time.Now())
+		if err != nil {
+			return err
+		}
+		return enc.Encode(sp)
+
+	case "list":
+		if len(args) != 0 {
+			return fmt.Errorf("usage: profiles [flags] list")
+		}
+		all, err := s.list()
+		if err != nil {
+			return err
+		}
+		return enc.Encode(slices.DeleteFunc(all, func(sp *StoredProfile) bool { return !filter(sp) }))
+
+	case "prune":
+		if len(args) != 0 {
+			return fmt.Errorf("usage: profiles [flags] prune")
+		}
+		if *profilesKeep <= 0 && *profilesOlderThan <= 0 {
+			return fmt.Errorf("prune requires -keep or -older-than")
+		}
+		removed, err := s.prune(filter, *profilesKeep, *profilesOlderThan, time.Now())
+		if err != nil {
+			return err
+		}
+		return enc.Encode(removed)
+
+	case "compare":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: profiles [flags] compare <base ID> <head ID>")
+		}
+		var ps [2]*profile.Profile
+		var sps [2]*StoredProfile
+		for i, id := range args {
+			sp, err := s.get(id)
+			if err != nil {
+				return err
+			}
+			p, err := readPprof(sp.File)
+			if err != nil {
+				return err
+			}
+			ps[i], sps[i] = (*profile.Profile)(p), sp
+		}
+		c, err := compareProfiles(ps[0], ps[1], *profilesIndex, *profilesTop)
+		if err != nil {
+			return err
+		}
+		c.Base, c.Head = sps[0], sps[1]
+		return enc.Encode(c)
+
+	default:
+		return fmt.Errorf("unknown profiles command %q, want add, list, prune, or compare", cmd)
+	}
+}
+
+// gitHead returns the commit checked out in the git repository
+// containing the current directory, or "" if there is none.
+func gitHead() string {
+	out, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/pprof/profile"
+)
+
+func profileBytes(t *testing.T, p *profile.Profile) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := p.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestProfileStore(t *testing.T) {
+	s := &profileStore{dir: t.TempDir()}
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	base := testProfile()
+	head := testProfile()
+	head.Sample[0].Value = []int64{9, 90000000} // parse got slower
+	meta := StoredProfile{Test: "TestServe", Package: "example.com/srv", Commit: "abc"}
+
+	old, err := s.add(profileBytes(t, base), meta, now.Add(-7*24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cur, err := s.add(profileBytes(t, head), meta, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if old.Kind != "cpu" {
+		t.Errorf("inferred kind = %q, want cpu", old.Kind)
+	}
+
+	all, err := s.list()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all[0].ID != cur.ID || all[1].ID != old.ID {
+		t.Fatalf("list = %+v, want [%s %s]", all, cur.ID, old.ID)
+	}
+
+	got, err := s.get(old.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Test != "TestServe" || got.Commit != "abc" || !got.Time.Equal(old.Time) {
+		t.Errorf("get(%s) = %+v, want %+v", old.ID, got, old)
+	}
+	if _, err := readPprof(got.File); err != nil {
+		t.Errorf("reading stored profile: %v", err)
+	}
+	if _, err := s.get("../" + old.ID); err == nil {
+		t.Errorf("get with a path succeeded")
+	}
+
+	bp, err := readPprof(old.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hp, err := readPprof(cur.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := compareProfiles((*profile.Profile)(bp), (*profile.Profile)(hp), "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.BaseTotal != 110000000 || c.HeadTotal != 170000000 {
+		t.Errorf("totals = %d, %d, want 110000000, 170000000", c.BaseTotal, c.HeadTotal)
+	}
+	if len(c.Functions) != 1 || c.Functions[0].Name != "main.parse" || c.Functions[0].HeadFlat != 90000000 {
+		t.Errorf("top changed functions = %+v, want main.parse", c.Functions)
+	}
+
+	removed, err := s.prune(func(*StoredProfile) bool { return true }, 1, 0, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0].ID != old.ID {
+		t.Errorf("prune removed %+v, want %s", removed, old.ID)
+	}
+	if all, _ := s.list(); len(all) != 1 {
+		t.Errorf("after prune, list = %+v, want 1 profile", all)
+	}
+}
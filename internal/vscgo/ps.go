@@ -0,0 +1,173 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"bufio"
+	"bytes"
+	"debug/buildinfo"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	psFlags = flag.NewFlagSet("ps", flag.ExitOnError)
+	psAll   = psFlags.Bool("all", false, "list all processes, not only Go programs")
+)
+
+// Process is a running process.
+type Process struct {
+	PID        int
+	Name       string // the command name, from /proc/<pid>/comm
+	Cmdline    []string
+	Executable string `json:",omitempty"` // unset if it cannot be read, as for processes of other users
+	// The following fields are set for Go programs, from the build
+	// information of the executable.
+	GoVersion string               `json:",omitempty"`
+	Path      string               `json:",omitempty"` // the main package path
+	Main      *debug.Module        `json:",omitempty"`
+	Settings  []debug.BuildSetting `json:",omitempty"`
+	Ports     []int                `json:",omitempty"` // the TCP ports the process listens on
+}
+
+// listProcesses lists the processes in procRoot, a proc file system
+// such as /proc. Unless all is set, only Go programs are listed. Kernel
+// threads, and processes that exit while they are read, are omitted.
+func listProcesses(procRoot string, all bool) ([]*Process, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+	builds := map[string]*debug.BuildInfo{}  // by executable, nil for non-Go executables
+	listeners := map[string]map[uint64]int{} // by network namespace: socket inode -> port
+	procs := []*Process{}
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(procRoot, e.Name())
+		cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline"))
+		if err != nil || len(cmdline) == 0 {
+			continue
+		}
+		p := &Process{PID: pid, Cmdline: strings.Split(strings.TrimSuffix(string(cmdline), "\x00"), "\x00")}
+		if comm, err := os.ReadFile(filepath.Join(dir, "comm")); err == nil {
+			p.Name = strings.TrimSpace(string(comm))
+		}
+
+		exe := filepath.Join(dir, "exe")
+		if p.Executable, err = os.Readlink(exe); err == nil {
+			info, ok := builds[p.Executable]
+			if !ok {
+				// The exe link opens the executable even if it
+				// was deleted or replaced since the process started.
+				info, _ = buildinfo.ReadFile(exe)
+				builds[p.Executable] = info
+			}
+			if info != nil {
+				p.GoVersion, p.Path, p.Settings = info.GoVersion, info.Path, info.Settings
+				if info.Main.Path != "" {
+					p.Main = &info.Main
+				}
+			}
+		}
+		if p.GoVersion == "" && !all {
+			continue
+		}
+
+		// The sockets of the process are listed in the tables of its
+		// network namespace.
+		ns, _ := os.Readlink(filepath.Join(dir, "ns", "net"))
+		ports, ok := listeners[ns]
+		if !ok {
+			netDir := filepath.Join(procRoot, "net")
+			if ns != "" {
+				netDir = filepath.Join(dir, "net")
+			}
+			ports = listeningSockets(netDir)
+			listeners[ns] = ports
+		}
+		p.Ports = processPorts(dir, ports)
+		procs = append(procs, p)
+	}
+	return procs, nil
+}
+
+// listeningSockets returns the ports of the listening TCP sockets in
+// the tables of netDir, such as /proc/net, by socket inode.
+func listeningSockets(netDir string) map[uint64]int {
+	ports := map[uint64]int{}
+	for _, name := range []string{"tcp", "tcp6"} {
+		data, err := os.ReadFile(filepath.Join(netDir, name))
+		if err != nil {
+			continue
+		}
+		s := bufio.NewScanner(bytes.NewReader(data))
+		s.Scan() // header
+		for s.Scan() {
+			// sl local_address rem_address st ... uid timeout inode
+			f := strings.Fields(s.Text())
+			if len(f) < 10 || f[3] != "0A" { // TCP_LISTEN
+				continue
+			}
+			_, port, ok := strings.Cut(f[1], ":")
+			p, err1 := strconv.ParseUint(port, 16, 16)
+			inode, err2 := strconv.ParseUint(f[9], 10, 64)
+			if ok && err1 == nil && err2 == nil && inode != 0 {
+				ports[inode] = int(p)
+			}
+		}
+	}
+	return ports
+}
+
+// processPorts returns the ports of the listening sockets among the
+// open files of the process in dir.
+func processPorts(dir string, listening map[uint64]int) []int {
+	if len(listening) == 0 {
+		return nil
+	}
+	fds, err := os.ReadDir(filepath.Join(dir, "fd"))
+	if err != nil {
+		return nil
+	}
+	var ports []int
+	for _, fd := range fds {
+		link, err := os.Readlink(filepath.Join(dir, "fd", fd.Name()))
+		if err != nil {
+			continue
+		}
+		s, ok := strings.CutPrefix(link, "socket:[")
+		if !ok {
+			continue
+		}
+		inode, err := strconv.ParseUint(strings.TrimSuffix(s, "]"), 10, 64)
+		if port, ok := listening[inode]; ok && err == nil {
+			ports = append(ports, port)
+		}
+	}
+	slices.Sort(ports)
+	return slices.Compact(ports)
+}
+
+func runPs(_ []string) error {
+	if runtime.GOOS != "linux" {
+		return fmt.Errorf("ps is only supported on Linux")
+	}
+	procs, err := listProcesses("/proc", *psAll)
+	if err != nil {
+		return err
+	}
+	return json.NewEncoder(os.Stdout).Encode(procs)
+}
@@ -0,0 +1,90 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+)
+
+func TestListProcesses(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skip(err)
+	}
+	root := writeFiles(t, t.TempDir(), map[string]string{
+		"100/cmdline": "prog\x00-addr\x00:8080\x00",
+		"100/comm":    "prog\n",
+		"101/cmdline": "script\x00",
+		"script":      "#!/bin/sh\n",
+		"102/cmdline": "", // a kernel thread
+		"net/tcp": `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 555 1 0000000000000000 100 0 0 10 0
+   1: 0100007F:9C40 0100007F:1F90 01 00000000:00000000 00:00000000 00000000  1000        0 556 1 0000000000000000 20 4 30 10 -1
+`,
+		"net/tcp6": `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000000000000:1F91 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 557 1 0000000000000000 100 0 0 10 0
+`,
+	})
+	script := filepath.Join(root, "script")
+	for link, target := range map[string]string{
+		"100/exe":  exe,
+		"101/exe":  script,
+		"100/fd/0": "/dev/null",
+		"100/fd/3": "socket:[555]",
+		"100/fd/4": "socket:[556]",
+		"100/fd/5": "socket:[557]",
+	} {
+		link = filepath.Join(root, link)
+		if err := os.MkdirAll(filepath.Dir(link), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, link); err != nil {
+			t.Skip(err)
+		}
+	}
+
+	procs, err := listProcesses(root, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(procs) != 1 {
+		t.Fatalf("got %d Go processes, want 1", len(procs))
+	}
+	p := procs[0]
+	if p.PID != 100 || p.Name != "prog" || !slices.Equal(p.Cmdline, []string{"prog", "-addr", ":8080"}) || p.Executable != exe {
+		t.Errorf("process = %+v, want prog", p)
+	}
+	if p.GoVersion != runtime.Version() || p.Path == "" || len(p.Settings) == 0 {
+		t.Errorf("process build information = %s %s %v, want that of the test", p.GoVersion, p.Path, p.Settings)
+	}
+	if !slices.Equal(p.Ports, []int{8080, 8081}) {
+		t.Errorf("ports = %v, want [8080 8081]", p.Ports)
+	}
+
+	procs, err = listProcesses(root, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(procs) != 2 || procs[1].PID != 101 || procs[1].GoVersion != "" || procs[1].Executable != script {
+		t.Errorf("all processes = %+v, want 100 and 101", procs)
+	}
+}
+
+func TestListProcessesSelf(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("requires /proc")
+	}
+	procs, err := listProcesses("/proc", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.ContainsFunc(procs, func(p *Process) bool { return p.PID == os.Getpid() && p.GoVersion == runtime.Version() }) {
+		t.Errorf("the test process is not among the Go processes")
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// RaceReport is a data race reported by the race detector.
+type RaceReport struct {
+	Package string `json:",omitempty"`
+	Test    string `json:",omitempty"` // the test running when the race was detected
+	// Accesses are the access that detected the race, then the earlier
+	// conflicting access.
+	Accesses []*RaceAccess
+	// Key identifies the race across runs, whatever the addresses,
+	// goroutines and order of the accesses. It is derived from the
+	// kinds and locations of the accesses.
+	Key string
+}
+
+// RaceAccess is a memory access involved in a data race. Function, File
+// and Line locate the innermost frame of its stack outside the runtime
+// and testing packages, if any.
+type RaceAccess struct {
+	Kind      string // read or write
+	Atomic    bool   `json:",omitempty"`
+	Previous  bool   `json:",omitempty"` // the earlier access
+	Addr      string
+	Function  string `json:",omitempty"`
+	File      string `json:",omitempty"`
+	Line      int    `json:",omitempty"`
+	Stack     []*RaceFrame
+	Goroutine *RaceGoroutine
+}
+
+// RaceGoroutine is the goroutine that made an access.
+type RaceGoroutine struct {
+	ID      int          `json:",omitempty"` // unset for the main goroutine
+	Main    bool         `json:",omitempty"`
+	State   string       `json:",omitempty"` // running or finished
+	Created []*RaceFrame `json:",omitempty"` // the stack that created the goroutine
+}
+
+// RaceFrame is a frame of a stack in a race report.
+type RaceFrame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+var (
+	// raceAccessRx matches the header of an access, as printed by
+	// ThreadSanitizer: "Read at", "Previous atomic write at" and so on.
+	raceAccessRx = regexp.MustCompile(`^(Previous )?((?i:atomic) )?(?i:(read|write)) at (\S+) by (?:(main) goroutine|goroutine (\d+)):$`)
+	// raceCreatedRx matches the header of the creation stack of a goroutine.
+	raceCreatedRx = regexp.MustCompile(`^Goroutine (\d+) \((\w+)\) created at:$`)
+	// raceFileRx matches the file:line line of a stack frame.
+	raceFileRx = regexp.MustCompile(`^ {6}(.+):(\d+)(?: \+0x[0-9a-f]+)?$`)
+)
+
+// raceParser parses the race reports in the output of a package.
+type raceParser struct {
+	report   *RaceReport
+	frames   *[]*RaceFrame // the stack being parsed
+	function string        // the function of the frame being parsed
+}
+
+// line processes a line of output, and returns the report it completes,
+// if any.
+func (p *raceParser) line(pkg, test, line string) *RaceReport {
+	switch {
+	case line == "WARNING: DATA RACE":
+		r := p.finish()
+		p.report = &RaceReport{Package: pkg, Test: test, Accesses: []*RaceAccess{}}
+		return r
+	case p.report == nil:
+		return nil
+	case strings.HasPrefix(line, "=================="):
+		return p.finish()
+	}
+
+	if m := raceAccessRx.FindStringSubmatch(line); m != nil {
+		a := &RaceAccess{
+			Kind:      strings.ToLower(m[3]),
+			Atomic:    m[2] != "",
+			Previous:  m[1] != "",
+			Addr:      m[4],
+			Stack:     []*RaceFrame{},
+			Goroutine: &RaceGoroutine{Main: m[5] != ""},
+		}
+		a.Goroutine.ID, _ = strconv.Atoi(m[6])
+		p.report.Accesses = append(p.report.Accesses, a)
+		p.frames, p.function = &a.Stack, ""
+	} else if m := raceCreatedRx.FindStringSubmatch(line); m != nil {
+		p.frames, p.function = nil, ""
+		id, _ := strconv.Atoi(m[1])
+		for _, a := range p.report.Accesses {
+			if g := a.Goroutine; g.ID == id && !g.Main {
+				g.State = m[2]
+				g.Created = []*RaceFrame{}
+				p.frames = &g.Created
+			}
+		}
+	} else if m := raceFileRx.FindStringSubmatch(line); m != nil && p.frames != nil {
+		ln, _ := strconv.Atoi(m[2])
+		*p.frames = append(*p.frames, &RaceFrame{Function: p.function, File: m[1], Line: ln})
+		p.function = ""
+	} else if fn, ok := strings.CutPrefix(line, "  "); ok && !strings.HasPrefix(fn, " ") {
+		p.function = strings.TrimSuffix(fn, "()")
+	}
+	return nil
+}
+
+// finish returns the report being parsed, if any, completed.
+func (p *raceParser) finish() *RaceReport {
+	r := p.report
+	p.report, p.frames, p.function = nil, nil, ""
+	if r == nil || len(r.Accesses) == 0 {
+		return nil
+	}
+	var locs []string
+	for _, a := range r.Accesses {
+		for _, f := range a.Stack {
+			if !isRaceInternal(f.Function) {
+				a.Function, a.File, a.Line = f.Function, f.File, f.Line
+				break
+			}
+		}
+		loc := fmt.Sprintf("%s %s %s:%d", a.Kind, a.Function, a.File, a.Line)
+		if a.Function == "" && len(a.Stack) > 0 {
+			loc = fmt.Sprintf("%s %s %s:%d", a.Kind, a.Stack[0].Function, a.Stack[0].File, a.Stack[0].Line)
+		}
+		locs = append(locs, loc)
+	}
+	slices.Sort(locs)
+	r.Key = fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(locs, "\n"))))[:16]
+	return r
+}
+
+// isRaceInternal reports whether fn is a function of the runtime or
+// testing packages, which are not where a race is to be fixed.
+func isRaceInternal(fn string) bool {
+	for _, prefix := range []string{"runtime.", "runtime/", "internal/", "sync/atomic.", "testing."} {
+		if strings.HasPrefix(fn, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// readRaceReports reads the output of go test -race, with or without
+// -json, from r and calls f with each race report.
+func readRaceReports(r io.Reader, f func(*RaceReport) error) error {
+	parsers := map[string]*raceParser{}
+	err := readTestEvents(r, func(e *TestEvent) error {
+		if e.Kind != "output" {
+			return nil
+		}
+		p := parsers[e.Package]
+		if p == nil {
+			p = &raceParser{}
+			parsers[e.Package] = p
+		}
+		if rep := p.line(e.Package, e.Test, strings.TrimSuffix(e.Output, "\n")); rep != nil {
+			return f(rep)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// Report the races whose output was cut short.
+	for _, pkg := range slices.Sorted(maps.Keys(parsers)) {
+		if rep := parsers[pkg].finish(); rep != nil {
+			if err := f(rep); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func runRaceReport(args []string) error {
+	var readers []io.Reader
+	for _, name := range args {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		readers = append(readers, f)
+	}
+	if len(readers) == 0 {
+		readers = append(readers, os.Stdin)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	return readRaceReports(io.MultiReader(readers...), func(r *RaceReport) error { return enc.Encode(r) })
+}
@@ -0,0 +1,147 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// raceOutput is the output of go test -race for a test with two races,
+// with some frames elided.
+const raceOutput = `=== RUN   TestRace
+==================
+WARNING: DATA RACE
+Read at 0x00c0000182c8 by goroutine 8:
+  example.com/r.TestRace.func1()
+      /src/r/r_test.go:15 +0x33
+
+Previous write at 0x00c0000182c8 by goroutine 7:
+  example.com/r.TestRace()
+      /src/r/r_test.go:18 +0x144
+  testing.tRunner()
+      /usr/local/go/src/testing/testing.go:2193 +0x21c
+
+Goroutine 8 (running) created at:
+  example.com/r.TestRace()
+      /src/r/r_test.go:14 +0x126
+  testing.tRunner()
+      /usr/local/go/src/testing/testing.go:2193 +0x21c
+
+Goroutine 7 (finished) created at:
+  testing.(*T).Run()
+      /usr/local/go/src/testing/testing.go:2258 +0xb12
+  main.main()
+      _testmain.go:46 +0x164
+==================
+==================
+WARNING: DATA RACE
+Write at 0x00c00007b020 by goroutine 9:
+  runtime.mapassign_fast64()
+      /usr/local/go/src/internal/runtime/maps/runtime_fast64.go:182 +0x0
+  example.com/r.TestRace.func2()
+      /src/r/r_test.go:22 +0x44
+
+Previous atomic read at 0x00c00007b020 by main goroutine:
+  runtime.mapaccess2_fast64()
+      /usr/local/go/src/internal/runtime/maps/runtime_fast64.go:22 +0x0
+
+Goroutine 9 (running) created at:
+  example.com/r.TestRace()
+      /src/r/r_test.go:22 +0x21c
+==================
+--- FAIL: TestRace (0.00s)
+    testing.go:1865: race detected during execution of test
+FAIL
+`
+
+func TestReadRaceReports(t *testing.T) {
+	var reps []*RaceReport
+	err := readRaceReports(strings.NewReader(raceOutput), func(r *RaceReport) error {
+		reps = append(reps, r)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reps) != 2 {
+		t.Fatalf("got %d reports, want 2", len(reps))
+	}
+
+	r := reps[0]
+	if len(r.Accesses) != 2 {
+		t.Fatalf("first report has %d accesses, want 2", len(r.Accesses))
+	}
+	cur, prev := r.Accesses[0], r.Accesses[1]
+	if got, want := describeRaceAccess(cur), "read 0x00c0000182c8 goroutine 8 (running) at example.com/r.TestRace.func1 /src/r/r_test.go:15"; got != want {
+		t.Errorf("access = %s, want %s", got, want)
+	}
+	if got, want := describeRaceAccess(prev), "previous write 0x00c0000182c8 goroutine 7 (finished) at example.com/r.TestRace /src/r/r_test.go:18"; got != want {
+		t.Errorf("previous access = %s, want %s", got, want)
+	}
+	if len(prev.Stack) != 2 || prev.Stack[1].Function != "testing.tRunner" || prev.Stack[1].Line != 2193 {
+		t.Errorf("previous access stack = %+v", prev.Stack)
+	}
+	if c := cur.Goroutine.Created; len(c) != 2 || c[0].File != "/src/r/r_test.go" || c[0].Line != 14 {
+		t.Errorf("goroutine 8 created at %+v, want /src/r/r_test.go:14 first", c)
+	}
+	if c := prev.Goroutine.Created; len(c) != 2 || c[1].Function != "main.main" || c[1].File != "_testmain.go" {
+		t.Errorf("goroutine 7 created at %+v, want main.main last", c)
+	}
+
+	// Races within the runtime are located at the first frame outside it.
+	r = reps[1]
+	if got, want := describeRaceAccess(r.Accesses[0]), "write 0x00c00007b020 goroutine 9 (running) at example.com/r.TestRace.func2 /src/r/r_test.go:22"; got != want {
+		t.Errorf("access = %s, want %s", got, want)
+	}
+	if got, want := describeRaceAccess(r.Accesses[1]), "previous atomic read 0x00c00007b020 main goroutine at  :0"; got != want {
+		t.Errorf("previous access = %s, want %s", got, want)
+	}
+	if reps[0].Key == reps[1].Key || len(reps[0].Key) != 16 {
+		t.Errorf("keys = %q, %q, want distinct keys", reps[0].Key, reps[1].Key)
+	}
+
+	// The same race, with the accesses in the other order and in a
+	// go test -json stream, has the same key.
+	swapped := strings.NewReplacer(
+		"Read at 0x00c0000182c8 by goroutine 8", "Previous read at 0x00c0000182c8 by goroutine 8",
+		"Previous write at 0x00c0000182c8 by goroutine 7", "Write at 0x00c0000182c8 by goroutine 7",
+	).Replace(raceOutput)
+	var stream strings.Builder
+	for line := range strings.Lines(swapped) {
+		data, _ := json.Marshal(map[string]string{"Action": "output", "Package": "example.com/r", "Test": "TestRace", "Output": line})
+		fmt.Fprintf(&stream, "%s\n", data)
+	}
+	var first *RaceReport
+	readRaceReports(strings.NewReader(stream.String()), func(r *RaceReport) error {
+		if first == nil {
+			first = r
+		}
+		return nil
+	})
+	if first == nil || first.Package != "example.com/r" || first.Test != "TestRace" || first.Key != reps[0].Key {
+		t.Errorf("report in JSON stream = %+v, want the key %s in TestRace", first, reps[0].Key)
+	}
+}
+
+func describeRaceAccess(a *RaceAccess) string {
+	var b strings.Builder
+	if a.Previous {
+		b.WriteString("previous ")
+	}
+	if a.Atomic {
+		b.WriteString("atomic ")
+	}
+	fmt.Fprintf(&b, "%s %s ", a.Kind, a.Addr)
+	if g := a.Goroutine; g.Main {
+		b.WriteString("main goroutine")
+	} else {
+		fmt.Fprintf(&b, "goroutine %d (%s)", g.ID, g.State)
+	}
+	fmt.Fprintf(&b, " at %s %s:%d", a.Function, a.File, a.Line)
+	return b.String()
+}
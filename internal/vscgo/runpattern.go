@@ -0,0 +1,80 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var (
+	runPatternFlags = flag.NewFlagSet("run-pattern", flag.ExitOnError)
+	runPatternFlag  = runPatternFlags.String("flag", "run", "go test flag to build the pattern for: run, bench or skip")
+)
+
+// RunPattern is a pattern for go test's -run, -bench or -skip flag that
+// matches exactly the given tests, and the go test arguments to use it.
+type RunPattern struct {
+	Pattern string
+	Args    []string
+}
+
+// testPathPattern returns a pattern that matches exactly the test with
+// the given path, such as TestFoo/sub test/#01, as -run, -bench and
+// -skip match it.
+//
+// The testing package splits both patterns and test names at slashes,
+// and matches each element of the name against the corresponding
+// element of the pattern, so each element is anchored separately.
+// Subtest names are rewritten as the testing package does, so that
+// a subtest can be named as given to t.Run or as reported by go test.
+func testPathPattern(path string) string {
+	elems := strings.Split(path, "/")
+	for i, e := range elems {
+		if i > 0 {
+			e = rewriteSubtestName(e)
+			if e == "" {
+				// A subtest with an empty name is named #00.
+				e = "#00"
+			}
+		}
+		elems[i] = "^" + regexp.QuoteMeta(e) + "$"
+	}
+	return strings.Join(elems, "/")
+}
+
+// testPathsPattern returns a pattern that matches exactly the tests with
+// the given paths. The testing package splits patterns at top-level |
+// into alternatives, each matched element by element.
+func testPathsPattern(paths []string) string {
+	pats := make([]string, len(paths))
+	for i, p := range paths {
+		pats[i] = testPathPattern(p)
+	}
+	return strings.Join(pats, "|")
+}
+
+func runRunPattern(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: run-pattern [-flag run|bench|skip] <test path>...")
+	}
+	p := &RunPattern{Pattern: testPathsPattern(args)}
+	switch *runPatternFlag {
+	case "run":
+		p.Args = []string{"-run=" + p.Pattern}
+	case "bench":
+		// Run no tests, only the benchmarks.
+		p.Args = []string{"-run=^$", "-bench=" + p.Pattern}
+	case "skip":
+		p.Args = []string{"-skip=" + p.Pattern}
+	default:
+		return fmt.Errorf("unknown flag %q, want run, bench or skip", *runPatternFlag)
+	}
+	return json.NewEncoder(os.Stdout).Encode(p)
+}
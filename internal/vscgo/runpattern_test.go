@@ -0,0 +1,83 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTestPathPattern(t *testing.T) {
+	for _, tc := range []struct {
+		path, want string
+	}{
+		{"TestFoo", `^TestFoo$`},
+		{"TestFoo/two words", `^TestFoo$/^two_words$`},
+		{"TestFoo/two_words", `^TestFoo$/^two_words$`},
+		{"TestFoo/one#01", `^TestFoo$/^one#01$`},
+		{"TestFoo/", `^TestFoo$/^#00$`},
+		{"TestFoo/a.b|c(d)[e]*", `^TestFoo$/^a\.b\|c\(d\)\[e\]\*$`},
+		{"TestFoo/nul\x00", `^TestFoo$/^nul\\x00$`},
+		{"TestΣυνάρτηση/x/y", `^TestΣυνάρτηση$/^x$/^y$`},
+	} {
+		if got := testPathPattern(tc.path); got != tc.want {
+			t.Errorf("testPathPattern(%q) = %q, want %q", tc.path, got, tc.want)
+		}
+	}
+	if got, want := testPathsPattern([]string{"TestA/x", "TestB"}), `^TestA$/^x$|^TestB$`; got != want {
+		t.Errorf("testPathsPattern = %q, want %q", got, want)
+	}
+}
+
+func TestTestPathPatternRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and runs tests")
+	}
+	dir := writeFiles(t, t.TempDir(), map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.21\n",
+		"m_test.go": `package m
+
+import "testing"
+
+func TestA(t *testing.T) {
+	for _, name := range []string{"a b", "a b", "a_b", "x|y", "", "[z]"} {
+		t.Run(name, func(t *testing.T) {})
+	}
+}
+
+func TestAB(t *testing.T) {
+	t.Run("a b", func(t *testing.T) {})
+}
+`,
+	})
+	for _, tc := range []struct {
+		paths []string
+		want  []string
+	}{
+		{[]string{"TestA/a b"}, []string{"TestA", "TestA/a_b"}},
+		{[]string{"TestA/a_b#01"}, []string{"TestA", "TestA/a_b#01"}},
+		{[]string{"TestA/x|y", "TestAB"}, []string{"TestA", "TestA/x|y", "TestAB", "TestAB/a_b"}},
+		{[]string{"TestA/"}, []string{"TestA", "TestA/#00"}},
+		{[]string{"TestA/[z]"}, []string{"TestA", "TestA/[z]"}},
+	} {
+		cmd := exec.Command("go", "test", "-v", "-count=1", "-run="+testPathsPattern(tc.paths), ".")
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("go test: %v\n%s", err, out)
+		}
+		var ran []string
+		for line := range strings.Lines(string(out)) {
+			if name, ok := strings.CutPrefix(strings.TrimSpace(line), "=== RUN   "); ok {
+				ran = append(ran, name)
+			}
+		}
+		if !slices.Equal(ran, tc.want) {
+			t.Errorf("%q ran %q, want %q", tc.paths, ran, tc.want)
+		}
+	}
+}
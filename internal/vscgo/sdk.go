@@ -0,0 +1,425 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/version"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+var (
+	sdkFlags = flag.NewFlagSet("sdk", flag.ExitOnError)
+	sdkBase  = sdkFlags.String("base", "https://go.dev/dl/", "base URL of the download index and archives")
+	sdkDir   = sdkFlags.String("dir", "", "directory of the SDKs (default: $HOME/sdk, as used by golang.org/dl)")
+	sdkAll   = sdkFlags.Bool("all", false, "list all releases, not only the supported ones")
+)
+
+// SDKRelease is a Go release in the download index.
+type SDKRelease struct {
+	Version string    `json:"version"`
+	Stable  bool      `json:"stable"`
+	Files   []SDKFile `json:"files"`
+}
+
+// SDKFile is a downloadable file of a Go release.
+type SDKFile struct {
+	Filename string `json:"filename"`
+	OS       string `json:"os"`
+	Arch     string `json:"arch"`
+	Version  string `json:"version"`
+	SHA256   string `json:"sha256"`
+	Size     int64  `json:"size"`
+	Kind     string `json:"kind"` // archive, installer or source
+}
+
+// SDK is a Go SDK, available for download or installed.
+type SDK struct {
+	Version   string
+	Stable    bool
+	Installed bool
+	Dir       string   `json:",omitempty"` // the GOROOT of the installed SDK
+	Archive   *SDKFile `json:",omitempty"` // the archive for this platform
+	Error     string   `json:",omitempty"` // set by verify if the SDK is broken
+}
+
+// unpackedMarker is the file golang.org/dl wrappers create in an SDK
+// once it is fully extracted.
+const unpackedMarker = ".unpacked-success"
+
+// sdkManager manages the Go SDKs in dir, downloaded from base.
+type sdkManager struct {
+	base   string
+	dir    string
+	client *http.Client
+}
+
+// index returns the releases in the download index, the most recent
+// first. Unless all is set, only supported releases are listed.
+func (m *sdkManager) index(ctx context.Context, all bool) ([]SDKRelease, error) {
+	u := strings.TrimSuffix(m.base, "/") + "/?mode=json"
+	if all {
+		u += "&include=all"
+	}
+	body, err := m.get(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	var rels []SDKRelease
+	if err := json.NewDecoder(body).Decode(&rels); err != nil {
+		return nil, fmt.Errorf("reading download index: %v", err)
+	}
+	return rels, nil
+}
+
+func (m *sdkManager) get(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// archive returns the archive of rel for the platform.
+func (rel *SDKRelease) archive(goos, goarch string) *SDKFile {
+	for i, f := range rel.Files {
+		if f.Kind == "archive" && f.OS == goos && f.Arch == goarch {
+			return &rel.Files[i]
+		}
+	}
+	return nil
+}
+
+// installed returns the versions of the SDKs in m.dir, identified by
+// the marker of a complete extraction.
+func (m *sdkManager) installed() ([]string, error) {
+	entries, err := os.ReadDir(m.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, e := range entries {
+		if e.IsDir() && version.IsValid(e.Name()) {
+			if _, err := os.Stat(filepath.Join(m.dir, e.Name(), unpackedMarker)); err == nil {
+				versions = append(versions, e.Name())
+			}
+		}
+	}
+	return versions, nil
+}
+
+// list returns the SDKs of the download index, and the installed ones,
+// the most recent first.
+func (m *sdkManager) list(ctx context.Context, all bool) ([]*SDK, error) {
+	rels, err := m.index(ctx, all)
+	if err != nil {
+		return nil, err
+	}
+	installed, err := m.installed()
+	if err != nil {
+		return nil, err
+	}
+	sdks := []*SDK{}
+	for _, rel := range rels {
+		sdks = append(sdks, &SDK{Version: rel.Version, Stable: rel.Stable, Archive: rel.archive(runtime.GOOS, runtime.GOARCH)})
+	}
+	for _, v := range installed {
+		i := slices.IndexFunc(sdks, func(s *SDK) bool { return s.Version == v })
+		if i < 0 {
+			// Installed SDKs of releases no longer in the index
+			// are listed too.
+			sdks = append(sdks, &SDK{Version: v, Stable: !isGoPrerelease(v)})
+			i = len(sdks) - 1
+		}
+		sdks[i].Installed, sdks[i].Dir = true, filepath.Join(m.dir, v)
+	}
+	slices.SortStableFunc(sdks, func(a, b *SDK) int { return version.Compare(b.Version, a.Version) })
+	return sdks, nil
+}
+
+// install downloads the archive of the release v for the platform,
+// verifies its checksum and extracts it into m.dir.
+func (m *sdkManager) install(ctx context.Context, v string) (*SDK, error) {
+	dst := filepath.Join(m.dir, v)
+	if _, err := os.Stat(filepath.Join(dst, unpackedMarker)); err == nil {
+		return &SDK{Version: v, Stable: !isGoPrerelease(v), Installed: true, Dir: dst}, nil
+	}
+	rels, err := m.index(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(rels, func(rel SDKRelease) bool { return rel.Version == v })
+	if i < 0 {
+		return nil, fmt.Errorf("%s is not in the download index", v)
+	}
+	file := rels[i].archive(runtime.GOOS, runtime.GOARCH)
+	if file == nil {
+		return nil, fmt.Errorf("%s has no archive for %s/%s", v, runtime.GOOS, runtime.GOARCH)
+	}
+	if err := os.MkdirAll(m.dir, 0777); err != nil {
+		return nil, err
+	}
+
+	// The archive is downloaded and extracted in m.dir, and the SDK
+	// only moved to its directory once complete.
+	tmp, err := os.MkdirTemp(m.dir, ".download-"+v+"-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	archive := filepath.Join(tmp, file.Filename)
+	if err := m.download(ctx, strings.TrimSuffix(m.base, "/")+"/"+file.Filename, archive, file.SHA256); err != nil {
+		return nil, err
+	}
+	root := filepath.Join(tmp, "go")
+	if strings.HasSuffix(file.Filename, ".zip") {
+		err = extractZip(archive, tmp)
+	} else {
+		err = extractTarGz(archive, tmp)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("extracting %s: %v", file.Filename, err)
+	}
+	if err := os.WriteFile(filepath.Join(root, unpackedMarker), nil, 0666); err != nil {
+		return nil, err
+	}
+	// A partial extraction, without the marker, is replaced.
+	if err := os.RemoveAll(dst); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(root, dst); err != nil {
+		return nil, err
+	}
+	return &SDK{Version: v, Stable: rels[i].Stable, Installed: true, Dir: dst, Archive: file}, nil
+}
+
+// download downloads url to the file dst, and checks that its SHA256
+// checksum is sum.
+func (m *sdkManager) download(ctx context.Context, url, dst, sum string) error {
+	body, err := m.get(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, h), body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("downloading %s: %v", url, err)
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(got, sum) {
+		return fmt.Errorf("%s: SHA256 checksum mismatch: got %s, want %s", url, got, sum)
+	}
+	return nil
+}
+
+// extractFile writes the file name of an archive, read from r, in dir.
+func extractFile(dir, name string, mode os.FileMode, r io.Reader) error {
+	if !filepath.IsLocal(name) {
+		return fmt.Errorf("invalid file name %q", name)
+	}
+	p := filepath.Join(dir, name)
+	if mode.IsDir() {
+		return os.MkdirAll(p, 0777)
+	}
+	if !mode.IsRegular() {
+		// Go SDK archives only have regular files.
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm()|0600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func extractTarGz(archive, dir string) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := extractFile(dir, hdr.Name, hdr.FileInfo().Mode(), tr); err != nil {
+			return err
+		}
+	}
+}
+
+func extractZip(archive, dir string) error {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	for _, zf := range zr.File {
+		r, err := zf.Open()
+		if err != nil {
+			return err
+		}
+		err = extractFile(dir, zf.Name, zf.Mode(), r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// remove removes the installed SDK of version v.
+func (m *sdkManager) remove(v string) error {
+	if !version.IsValid(v) {
+		return fmt.Errorf("invalid Go version %q", v)
+	}
+	dst := filepath.Join(m.dir, v)
+	if _, err := os.Stat(dst); err != nil {
+		return fmt.Errorf("%s is not installed", v)
+	}
+	return os.RemoveAll(dst)
+}
+
+// verify checks that the installed SDK of version v is complete and that
+// its go command reports version v.
+func (m *sdkManager) verify(ctx context.Context, v string) *SDK {
+	s := &SDK{Version: v, Stable: !isGoPrerelease(v), Dir: filepath.Join(m.dir, v)}
+	if _, err := os.Stat(filepath.Join(s.Dir, unpackedMarker)); err != nil {
+		s.Dir, s.Error = "", fmt.Sprintf("%s is not installed", v)
+		return s
+	}
+	s.Installed = true
+	data, err := os.ReadFile(filepath.Join(s.Dir, "VERSION"))
+	if err != nil {
+		s.Error = err.Error()
+		return s
+	}
+	if got, _, _ := strings.Cut(string(data), "\n"); got != v {
+		s.Error = fmt.Sprintf("VERSION file reports %s", got)
+		return s
+	}
+	cmd := exec.CommandContext(ctx, filepath.Join(s.Dir, "bin", "go"+exeSuffix()), "version")
+	cmd.Env = append(os.Environ(), "GOROOT="+s.Dir, "GOTOOLCHAIN=local")
+	out, err := cmd.Output()
+	if err != nil {
+		s.Error = fmt.Sprintf("go version: %v", err)
+	} else if f := strings.Fields(string(out)); len(f) < 3 || f[2] != v {
+		s.Error = fmt.Sprintf("go version reports %s", bytes.TrimSpace(out))
+	}
+	return s
+}
+
+func exeSuffix() string {
+	if runtime.GOOS == "windows" {
+		return ".exe"
+	}
+	return ""
+}
+
+func runSDK(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: sdk [flags] list|install|remove|verify [<version>]")
+	}
+	dir := *sdkDir
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		dir = filepath.Join(home, "sdk")
+	}
+	m := &sdkManager{base: *sdkBase, dir: dir, client: http.DefaultClient}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if args[0] == "list" {
+		if len(args) != 1 {
+			return fmt.Errorf("usage: sdk list")
+		}
+		sdks, err := m.list(ctx, *sdkAll)
+		if err != nil {
+			return err
+		}
+		return json.NewEncoder(os.Stdout).Encode(sdks)
+	}
+	if len(args) != 2 {
+		return fmt.Errorf("usage: sdk %s <version>", args[0])
+	}
+	v := args[1]
+	if !strings.HasPrefix(v, "go") {
+		v = "go" + v
+	}
+	switch args[0] {
+	case "install":
+		s, err := m.install(ctx, v)
+		if err != nil {
+			return err
+		}
+		return json.NewEncoder(os.Stdout).Encode(s)
+	case "remove":
+		return m.remove(v)
+	case "verify":
+		s := m.verify(ctx, v)
+		if err := json.NewEncoder(os.Stdout).Encode(s); err != nil {
+			return err
+		}
+		if s.Error != "" {
+			return fmt.Errorf("%s: %s", v, s.Error)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown sdk command %q: want list, install, remove or verify", args[0])
+}
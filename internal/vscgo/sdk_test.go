@@ -0,0 +1,167 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// sdkArchive returns a .tar.gz SDK archive of version v, whose go
+// command is a script that prints its version.
+func sdkArchive(t *testing.T, v string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	for _, f := range []struct {
+		name, content string
+		mode          int64
+	}{
+		{"go/VERSION", v + "\ntime 2026-01-02T15:04:05Z\n", 0644},
+		{"go/bin/go", "#!/bin/sh\necho go version " + v + " " + runtime.GOOS + "/" + runtime.GOARCH + "\n", 0755},
+		{"go/src/fmt/print.go", "package fmt\n", 0644},
+	} {
+		if err := tw.WriteHeader(&tar.Header{Name: f.name, Mode: f.mode, Size: int64(len(f.content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(f.content))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// serveSDKs serves a download index of the versions, of which corrupt
+// ones have archives that do not match their checksums.
+func serveSDKs(t *testing.T, versions []string, corrupt map[string]bool) *httptest.Server {
+	t.Helper()
+	files := map[string][]byte{}
+	var rels []SDKRelease
+	for _, v := range versions {
+		data := sdkArchive(t, v)
+		sum := sha256.Sum256(data)
+		name := v + "." + runtime.GOOS + "-" + runtime.GOARCH + ".tar.gz"
+		files[name] = data
+		if corrupt[v] {
+			files[name] = append([]byte(nil), data[:len(data)-1]...)
+		}
+		rels = append(rels, SDKRelease{Version: v, Stable: !isGoPrerelease(v), Files: []SDKFile{
+			{Filename: v + ".src.tar.gz", Version: v, Kind: "source"},
+			{Filename: name, OS: runtime.GOOS, Arch: runtime.GOARCH, Version: v, SHA256: hex.EncodeToString(sum[:]), Size: int64(len(data)), Kind: "archive"},
+		}})
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/dl/")
+		if name == "" && r.URL.Query().Get("mode") == "json" {
+			list := rels
+			if r.URL.Query().Get("include") != "all" {
+				list = rels[:1]
+			}
+			json.NewEncoder(w).Encode(list)
+			return
+		}
+		data, ok := files[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSDKManager(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the go command of the test SDKs is a shell script")
+	}
+	srv := serveSDKs(t, []string{"go1.99.1", "go1.99rc1", "go1.98.5"}, map[string]bool{"go1.98.5": true})
+	m := &sdkManager{base: srv.URL + "/dl/", dir: filepath.Join(t.TempDir(), "sdk"), client: srv.Client()}
+	ctx := context.Background()
+
+	sdks, err := m.list(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sdks) != 3 || sdks[0].Version != "go1.99.1" || sdks[1].Version != "go1.99rc1" || sdks[1].Stable || sdks[0].Archive == nil || sdks[0].Installed {
+		t.Fatalf("list = %+v, want go1.99.1, go1.99rc1 and go1.98.5 not installed", sdks)
+	}
+
+	s, err := m.install(ctx, "go1.99.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(m.dir, "go1.99.1"); !s.Installed || s.Dir != want {
+		t.Errorf("install = %+v, want it in %s", s, want)
+	}
+	if _, err := os.Stat(filepath.Join(s.Dir, "src", "fmt", "print.go")); err != nil {
+		t.Errorf("SDK not extracted: %v", err)
+	}
+	if s := m.verify(ctx, "go1.99.1"); s.Error != "" {
+		t.Errorf("verify = %+v, want no error", s)
+	}
+
+	// The archive of go1.98.5 does not match its checksum.
+	if _, err := m.install(ctx, "go1.98.5"); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("install of a corrupt archive: %v, want a checksum mismatch", err)
+	}
+	if _, err := os.Stat(filepath.Join(m.dir, "go1.98.5")); err == nil {
+		t.Errorf("corrupt archive extracted")
+	}
+	if _, err := m.install(ctx, "go1.97.0"); err == nil {
+		t.Errorf("install of a missing release succeeded")
+	}
+
+	// Installed SDKs are listed even if they are not in the index.
+	sdks, err = m.list(ctx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sdks) != 1 || !sdks[0].Installed || sdks[0].Dir != s.Dir {
+		t.Errorf("list = %+v, want go1.99.1 installed", sdks)
+	}
+
+	// An SDK whose go command reports another version is broken.
+	if err := os.WriteFile(filepath.Join(s.Dir, "bin", "go"), []byte("#!/bin/sh\necho go version go1.2 "+runtime.GOOS+"/"+runtime.GOARCH+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if s := m.verify(ctx, "go1.99.1"); !strings.Contains(s.Error, "go1.2") {
+		t.Errorf("verify of a broken SDK = %+v, want an error", s)
+	}
+
+	if err := m.remove("go1.99.1"); err != nil {
+		t.Fatal(err)
+	}
+	if s := m.verify(ctx, "go1.99.1"); s.Installed || s.Error == "" {
+		t.Errorf("verify of a removed SDK = %+v, want it not installed", s)
+	}
+	if err := m.remove("go1.99.1"); err == nil {
+		t.Errorf("removing a missing SDK succeeded")
+	}
+}
+
+func TestExtractFileOutsideDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := extractFile(dir, "../escape", 0644, strings.NewReader("x")); err == nil {
+		t.Errorf("extracting ../escape succeeded")
+	}
+}
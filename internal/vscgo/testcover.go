@@ -0,0 +1,258 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/cover"
+)
+
+var (
+	testCoverFlags    = flag.NewFlagSet("test-coverage", flag.ExitOnError)
+	testCoverDir      = testCoverFlags.String("C", "", "directory to run go commands in (default: current directory)")
+	testCoverRun      = testCoverFlags.String("run", "", "run only the tests matching this regular expression, as go test -run")
+	testCoverPkgs     = testCoverFlags.String("coverpkg", "", "packages to collect coverage for, as go test -coverpkg (default: the tested package)")
+	testCoverParallel = testCoverFlags.Int("p", runtime.GOMAXPROCS(0), "number of tests to run in parallel")
+	testCoverOut      = testCoverFlags.String("o", "", "also write the index to this file")
+	testCoverIndex    = testCoverFlags.String("index", "", "read the index from this file, written by -o, instead of running tests")
+	testCoverLine     = testCoverFlags.String("line", "", "print only the tests covering this file:line")
+)
+
+// TestCoverageIndex maps each test of a package to the blocks it covers.
+type TestCoverageIndex struct {
+	Package string
+	Tests   []*TestCoverage
+}
+
+// TestCoverage is the coverage of a single test.
+type TestCoverage struct {
+	Name   string
+	Failed bool `json:",omitempty"`
+	Files  []*TestFileCoverage
+}
+
+// TestFileCoverage is the set of blocks of a file covered by a test.
+type TestFileCoverage struct {
+	Path    string
+	Covered []CoverRange
+}
+
+// LineTests is the answer to "which tests cover this line?".
+type LineTests struct {
+	Path  string
+	Line  int
+	Tests []string
+}
+
+// listTests returns the names of the tests, examples and fuzz targets
+// of pkg matching the run pattern.
+func listTests(ctx context.Context, dir, pkg, run string) ([]string, error) {
+	if run == "" {
+		run = "."
+	}
+	// Unlike go list, go test passes the arguments after "--" to
+	// the test binary, so pkg cannot be separated from the flags.
+	cmd := exec.CommandContext(ctx, "go", "test", "-list", run, pkg)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go test -list: %v: %s%s", err, out, stderr.Bytes())
+	}
+	var names []string
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		name := strings.TrimSpace(s.Text())
+		if testNameRx.MatchString(name) {
+			names = append(names, name)
+		}
+	}
+	return names, s.Err()
+}
+
+var testNameRx = regexp.MustCompile(`^(Test|Example|Fuzz)\w*$`)
+
+// buildTestIndex runs each test of pkg matching run on its own, with
+// coverage enabled, and records the blocks each test covers.
+// The test binary is built once and run in the package directory.
+func buildTestIndex(ctx context.Context, dir, pkg, run, coverpkg string, parallel int) (*TestCoverageIndex, error) {
+	names, err := listTests(ctx, dir, pkg, run)
+	if err != nil {
+		return nil, err
+	}
+	pkgDir, err := goList(ctx, dir, "{{.Dir}}", pkg)
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := os.MkdirTemp("", "vscgo-test-coverage-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	bin := filepath.Join(tmp, "pkg.test")
+	if runtime.GOOS == "windows" {
+		bin += ".exe"
+	}
+	args := []string{"test", "-c", "-o", bin, "-cover", "-covermode=set"}
+	if coverpkg != "" {
+		args = append(args, "-coverpkg="+coverpkg)
+	}
+	cmd := exec.CommandContext(ctx, "go", append(args, pkg)...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("go test -c: %v: %s", err, out)
+	}
+
+	index := &TestCoverageIndex{Package: pkg, Tests: make([]*TestCoverage, len(names))}
+	profiles := make([][]*cover.Profile, len(names))
+	errs := make([]error, len(names))
+	sem := make(chan struct{}, max(parallel, 1))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			out := filepath.Join(tmp, strconv.Itoa(i)+".out")
+			cmd := exec.CommandContext(ctx, bin, "-test.run=^"+regexp.QuoteMeta(name)+"$", "-test.coverprofile="+out)
+			cmd.Dir = pkgDir
+			var exitErr *exec.ExitError
+			err := cmd.Run()
+			if err != nil && !errors.As(err, &exitErr) {
+				errs[i] = err
+				return
+			}
+			index.Tests[i] = &TestCoverage{Name: name, Failed: err != nil}
+			profiles[i], errs[i] = cover.ParseProfiles(out)
+			if errors.Is(errs[i], fs.ErrNotExist) {
+				// The test binary exited before writing the profile.
+				errs[i] = nil
+			}
+		})
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	paths, err := resolveCoverFiles(dir, slices.Concat(profiles...))
+	if err != nil {
+		return nil, err
+	}
+	for i, t := range index.Tests {
+		t.Files = []*TestFileCoverage{}
+		for _, fc := range coverageReport(profiles[i], paths).Files {
+			if len(fc.Covered) > 0 {
+				t.Files = append(t.Files, &TestFileCoverage{Path: fc.Path, Covered: fc.Covered})
+			}
+		}
+	}
+	return index, nil
+}
+
+// testsCoveringLine returns the names of the tests in the index that
+// cover the line of the file. Relative paths match the end of the
+// indexed file paths.
+func testsCoveringLine(index *TestCoverageIndex, path string, line int) *LineTests {
+	res := &LineTests{Path: path, Line: line, Tests: []string{}}
+	for _, t := range index.Tests {
+		covers := slices.ContainsFunc(t.Files, func(f *TestFileCoverage) bool {
+			return samePath(f.Path, path) && slices.ContainsFunc(f.Covered, func(b CoverRange) bool {
+				return b.StartLine <= line && line <= b.EndLine
+			})
+		})
+		if covers {
+			res.Tests = append(res.Tests, t.Name)
+		}
+	}
+	return res
+}
+
+// samePath reports whether the file path indexed refers to the file path p,
+// which may be relative.
+func samePath(indexed, p string) bool {
+	indexed, p = filepath.Clean(indexed), filepath.Clean(p)
+	if filepath.IsAbs(p) {
+		return indexed == p
+	}
+	return indexed == p || strings.HasSuffix(indexed, string(filepath.Separator)+p)
+}
+
+// goList runs go list -f format for a single package in dir
+// and returns its output.
+func goList(ctx context.Context, dir, format, pkg string) (string, error) {
+	cmd := exec.CommandContext(ctx, "go", "list", "-f", format, "--", pkg)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("go list %s: %v: %s", pkg, err, stderr.Bytes())
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func runTestCoverage(args []string) error {
+	ctx := context.Background()
+	var index *TestCoverageIndex
+	switch {
+	case *testCoverIndex != "" && len(args) == 0:
+		data, err := os.ReadFile(*testCoverIndex)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &index); err != nil {
+			return fmt.Errorf("%s: %v", *testCoverIndex, err)
+		}
+	case *testCoverIndex == "" && len(args) == 1:
+		var err error
+		index, err = buildTestIndex(ctx, *testCoverDir, args[0], *testCoverRun, *testCoverPkgs, *testCoverParallel)
+		if err != nil {
+			return err
+		}
+		if *testCoverOut != "" {
+			data, err := json.Marshal(index)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(*testCoverOut, data, 0644); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("usage: test-coverage [flags] <package> or test-coverage -index <file> -line <file:line>")
+	}
+
+	if *testCoverLine == "" {
+		return json.NewEncoder(os.Stdout).Encode(index)
+	}
+	i := strings.LastIndex(*testCoverLine, ":")
+	if i < 0 {
+		return fmt.Errorf("invalid -line %q, want file:line", *testCoverLine)
+	}
+	line, err := strconv.Atoi((*testCoverLine)[i+1:])
+	if err != nil {
+		return fmt.Errorf("invalid -line %q, want file:line", *testCoverLine)
+	}
+	return json.NewEncoder(os.Stdout).Encode(testsCoveringLine(index, (*testCoverLine)[:i], line))
+}
@@ -0,0 +1,88 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBuildTestIndex(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and runs tests")
+	}
+	dir := writeFiles(t, t.TempDir(), map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.21\n",
+		"m.go": `package m
+
+func Add(a, b int) int {
+	return a + b
+}
+
+func Sub(a, b int) int {
+	return a - b
+}
+`,
+		"m_test.go": `package m
+
+import "testing"
+
+func TestAdd(t *testing.T) {
+	if Add(1, 2) != 3 {
+		t.Fatal("bad")
+	}
+}
+
+func TestSub(t *testing.T) {
+	if Sub(1, 2) != 0 {
+		t.Fatal("expected failure")
+	}
+}
+
+func TestBoth(t *testing.T) {
+	Add(1, 2)
+	Sub(1, 2)
+}
+
+func BenchmarkAdd(b *testing.B) {}
+`,
+	})
+	index, err := buildTestIndex(context.Background(), dir, ".", "", "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	var failed []string
+	for _, tc := range index.Tests {
+		names = append(names, tc.Name)
+		if tc.Failed {
+			failed = append(failed, tc.Name)
+		}
+	}
+	if want := []string{"TestAdd", "TestSub", "TestBoth"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("tests = %v, want %v", names, want)
+	}
+	if want := []string{"TestSub"}; !reflect.DeepEqual(failed, want) {
+		t.Errorf("failed tests = %v, want %v", failed, want)
+	}
+
+	for _, tc := range []struct {
+		path string
+		line int
+		want []string
+	}{
+		{filepath.Join(dir, "m.go"), 4, []string{"TestAdd", "TestBoth"}},
+		{"m.go", 8, []string{"TestSub", "TestBoth"}},
+		{"m.go", 6, []string{}},
+		{"other.go", 4, []string{}},
+	} {
+		got := testsCoveringLine(index, tc.path, tc.line)
+		if !reflect.DeepEqual(got.Tests, tc.want) {
+			t.Errorf("tests covering %s:%d = %v, want %v", tc.path, tc.line, got.Tests, tc.want)
+		}
+	}
+}
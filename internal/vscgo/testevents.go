@@ -0,0 +1,413 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// goTestEvent is an event of a go test -json stream: either a test event
+// of test2json, or a build event of the go command.
+// See go help test and go help buildjson.
+type goTestEvent struct {
+	Time        time.Time
+	Action      string
+	Package     string
+	Test        string
+	Elapsed     float64
+	Output      string
+	OutputType  string
+	FailedBuild string
+	Key         string
+	Value       string
+	ImportPath  string // for build events
+}
+
+// TestEvent is a normalized event of a go test -json stream.
+//
+// Kind is one of the test2json actions start, run, pause, cont, pass,
+// fail, skip, bench, output and attr, or one of:
+//
+//	panic      - a test panicked; Test is the test that panicked, which
+//	             may differ from the test go test attributes the panic to
+//	build-fail - a package failed to build; Package is the package that
+//	             failed, Output the build output
+//
+// Framing output, such as "=== RUN" and "--- FAIL:" lines, is dropped,
+// and the output of a panic is attributed to the test that panicked.
+type TestEvent struct {
+	Kind        string
+	Time        time.Time      `json:",omitzero"`
+	Package     string         `json:",omitempty"`
+	Test        string         `json:",omitempty"` // full name, such as TestFoo/sub
+	Parent      string         `json:",omitempty"` // full name of the parent test, for subtests
+	Name        string         `json:",omitempty"` // name of the test within its parent
+	Elapsed     float64        `json:",omitempty"`
+	Output      string         `json:",omitempty"`
+	Cached      bool           `json:",omitempty"` // for package pass events of cached results
+	SkipReason  string         `json:",omitempty"`
+	Failures    []*TestFailure `json:",omitempty"` // for fail and build-fail events
+	Panic       *TestPanic     `json:",omitempty"`
+	FailedBuild string         `json:",omitempty"` // for package fail events caused by a build failure
+	Key         string         `json:",omitempty"` // for attr events
+	Value       string         `json:",omitempty"` // for attr events
+}
+
+// TestFailure is a message reported by a test or the compiler,
+// with its location. File is the file name as printed: the base
+// name for test messages, and a path relative to the go command's
+// working directory for build errors.
+type TestFailure struct {
+	File    string `json:",omitempty"`
+	Line    int    `json:",omitempty"`
+	Col     int    `json:",omitempty"`
+	Message string
+}
+
+// TestPanic describes a panic. File and Line locate the innermost
+// frame of the panicking goroutine outside the runtime and testing
+// packages.
+type TestPanic struct {
+	Message  string
+	Function string `json:",omitempty"`
+	File     string `json:",omitempty"`
+	Line     int    `json:",omitempty"`
+	Stack    string
+}
+
+var (
+	// testMessageRx matches the first line of a message printed by
+	// t.Log, t.Error, t.Skip and the like.
+	testMessageRx = regexp.MustCompile(`^(\s*)([^\s:]+\.go):(\d+): (.*)$`)
+	// buildErrorRx matches a compiler error.
+	buildErrorRx = regexp.MustCompile(`^(\S+\.go):(\d+)(?::(\d+))?: (.*)$`)
+	// frameRx matches the framing lines of go test output.
+	frameRx = regexp.MustCompile(`^(=== (RUN|PAUSE|CONT|NAME)\s|\s*--- (PASS|FAIL|SKIP|BENCH): |PASS$|FAIL$|(ok|FAIL|\?)\s+\S+\s)`)
+	// panicEndRx matches the lines printed after the stack of a panic
+	// that ended the test binary.
+	panicEndRx = regexp.MustCompile(`^(exit status \d+|FAIL\s+\S+\s.*)$`)
+	// stackFileRx matches the file:line line of a stack frame.
+	stackFileRx = regexp.MustCompile(`^\t(.+\.go):(\d+)(?: \+0x[0-9a-f]+)?$`)
+)
+
+// testEventNormalizer converts go test -json events to TestEvents.
+type testEventNormalizer struct {
+	pkgs  map[string]*testPkgState
+	build map[string]*TestEvent // build failures in progress, by import path
+}
+
+type testPkgState struct {
+	tests    map[string]bool
+	messages map[string][]*testMessage
+	// failed lists the tests that failed since the last event that was
+	// not a failure. A panicking test and its parents fail in a burst,
+	// innermost first, before the panic is printed.
+	failed []string
+	panic  *TestEvent // the panic being printed, if any
+	cached bool       // whether the package's result was cached
+}
+
+type testMessage struct {
+	TestFailure
+	indent  string
+	isError bool
+}
+
+func newTestEventNormalizer() *testEventNormalizer {
+	return &testEventNormalizer{
+		pkgs:  map[string]*testPkgState{},
+		build: map[string]*TestEvent{},
+	}
+}
+
+func (n *testEventNormalizer) pkg(name string) *testPkgState {
+	ps := n.pkgs[name]
+	if ps == nil {
+		ps = &testPkgState{tests: map[string]bool{}, messages: map[string][]*testMessage{}}
+		n.pkgs[name] = ps
+	}
+	return ps
+}
+
+// parent returns the parent of test, the longest prefix of its name
+// that names a test that was run, and its name within the parent.
+// Subtest names may contain slashes, so the name alone is ambiguous.
+func (ps *testPkgState) parent(test string) (parent, name string) {
+	for i := strings.LastIndexByte(test, '/'); i > 0; i = strings.LastIndexByte(test[:i], '/') {
+		if ps.tests[test[:i]] {
+			return test[:i], test[i+1:]
+		}
+	}
+	return "", test
+}
+
+func (n *testEventNormalizer) event(ps *testPkgState, kind string, ev *goTestEvent, test string) *TestEvent {
+	e := &TestEvent{
+		Kind:    kind,
+		Time:    ev.Time,
+		Package: ev.Package,
+		Test:    test,
+		Elapsed: ev.Elapsed,
+	}
+	if test != "" {
+		e.Parent, e.Name = ps.parent(test)
+	}
+	return e
+}
+
+// process returns the normalized events for ev.
+func (n *testEventNormalizer) process(ev *goTestEvent) []*TestEvent {
+	switch ev.Action {
+	case "build-output":
+		b := n.build[ev.ImportPath]
+		if b == nil {
+			b = &TestEvent{Kind: "build-fail", Package: buildPackage(ev.ImportPath)}
+			n.build[ev.ImportPath] = b
+		}
+		b.Output += ev.Output
+		line := strings.TrimSuffix(ev.Output, "\n")
+		if m := buildErrorRx.FindStringSubmatch(line); m != nil {
+			ln, _ := strconv.Atoi(m[2])
+			col, _ := strconv.Atoi(m[3])
+			b.Failures = append(b.Failures, &TestFailure{File: m[1], Line: ln, Col: col, Message: m[4]})
+		}
+		return nil
+	case "build-fail":
+		b := n.build[ev.ImportPath]
+		delete(n.build, ev.ImportPath)
+		if b == nil {
+			b = &TestEvent{Kind: "build-fail", Package: buildPackage(ev.ImportPath)}
+		}
+		return []*TestEvent{b}
+	}
+
+	ps := n.pkg(ev.Package)
+	var res []*TestEvent
+	if ev.Action != "output" && ps.panic != nil {
+		res = append(res, ps.panic)
+		ps.panic = nil
+	}
+
+	switch ev.Action {
+	case "output":
+		return append(res, n.output(ps, ev)...)
+
+	case "fail":
+		e := n.event(ps, "fail", ev, ev.Test)
+		e.FailedBuild = ev.FailedBuild
+		e.Failures = failures(ps.messages[ev.Test])
+		if ev.Test != "" {
+			ps.failed = append(ps.failed, ev.Test)
+		}
+		return append(res, e)
+
+	case "skip":
+		e := n.event(ps, "skip", ev, ev.Test)
+		var reason []string
+		for _, m := range ps.messages[ev.Test] {
+			reason = append(reason, m.Message)
+		}
+		e.SkipReason = strings.Join(reason, "\n")
+		if ev.Test == "" && e.SkipReason == "" {
+			e.SkipReason = "no test files"
+		}
+		ps.failed = nil
+		return append(res, e)
+
+	case "pass":
+		e := n.event(ps, "pass", ev, ev.Test)
+		e.Cached = ev.Test == "" && ps.cached
+		ps.failed = nil
+		return append(res, e)
+
+	case "run":
+		ps.tests[ev.Test] = true
+		fallthrough
+	default:
+		e := n.event(ps, ev.Action, ev, ev.Test)
+		e.Key, e.Value = ev.Key, ev.Value
+		if ev.Action != "attr" {
+			ps.failed = nil
+		}
+		return append(res, e)
+	}
+}
+
+// output processes an output event.
+func (n *testEventNormalizer) output(ps *testPkgState, ev *goTestEvent) []*TestEvent {
+	line := strings.TrimSuffix(ev.Output, "\n")
+	test := ev.Test
+
+	var res []*TestEvent
+	if ps.panic != nil && panicEndRx.MatchString(line) {
+		res = append(res, ps.panic)
+		ps.panic = nil
+	}
+	if ps.panic == nil && strings.HasPrefix(line, "panic: ") {
+		// The innermost test of the last burst of failures panicked.
+		// If there is none, the panic happened outside of a test
+		// function, or in a test that did not report its failure.
+		if len(ps.failed) > 0 {
+			test = ps.failed[0]
+		}
+		p := n.event(ps, "panic", ev, test)
+		p.Elapsed = 0
+		msg := strings.TrimPrefix(line, "panic: ")
+		if i := strings.Index(msg, " [recovered"); i >= 0 {
+			msg = msg[:i]
+		}
+		p.Panic = &TestPanic{Message: msg}
+		ps.panic = p
+	}
+	if p := ps.panic; p != nil {
+		test = p.Test
+		p.Panic.Stack += ev.Output
+		if p.Panic.File == "" {
+			locatePanic(p.Panic)
+		}
+	}
+
+	if ev.OutputType == "frame" || frameRx.MatchString(line) {
+		if ev.Test == "" && strings.HasPrefix(line, "ok") && strings.HasSuffix(line, "(cached)") {
+			ps.cached = true
+		}
+		return res
+	}
+	if ev.OutputType != "frame" && ps.panic == nil && len(strings.TrimSpace(line)) > 0 {
+		ps.failed = nil
+	}
+
+	msgs := ps.messages[test]
+	if m := testMessageRx.FindStringSubmatch(line); m != nil && ps.panic == nil {
+		ln, _ := strconv.Atoi(m[3])
+		ps.messages[test] = append(msgs, &testMessage{
+			TestFailure: TestFailure{File: m[2], Line: ln, Message: m[4]},
+			indent:      m[1],
+			isError:     ev.OutputType == "error",
+		})
+	} else if k := len(msgs); k > 0 && ps.panic == nil &&
+		(ev.OutputType == "error-continue" || ev.OutputType == "" && strings.HasPrefix(line, msgs[k-1].indent+"    ")) {
+		msgs[k-1].Message += "\n" + strings.TrimSpace(line)
+	}
+
+	e := n.event(ps, "output", ev, test)
+	e.Output = ev.Output
+	return append(res, e)
+}
+
+// locatePanic sets the location of the panic to the first frame of the
+// stack printed so far that is not in the runtime or testing packages.
+func locatePanic(p *TestPanic) {
+	lines := strings.Split(p.Stack, "\n")
+	for i := 1; i < len(lines); i++ {
+		m := stackFileRx.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		fn := lines[i-1]
+		if j := strings.LastIndexByte(fn, '('); j > 0 {
+			fn = fn[:j]
+		}
+		if fn == "panic" || strings.HasPrefix(fn, "runtime.") || strings.HasPrefix(fn, "runtime/") || strings.HasPrefix(fn, "testing.") || strings.HasPrefix(fn, "created by ") {
+			continue
+		}
+		p.Function = fn
+		p.File = m[1]
+		p.Line, _ = strconv.Atoi(m[2])
+		return
+	}
+}
+
+// failures returns the messages of a failed test. If some messages
+// are known to be errors, as opposed to logs, only those are returned.
+func failures(msgs []*testMessage) []*TestFailure {
+	anyErrors := false
+	for _, m := range msgs {
+		anyErrors = anyErrors || m.isError
+	}
+	var res []*TestFailure
+	for _, m := range msgs {
+		if m.File != "" && (m.isError || !anyErrors) {
+			res = append(res, &m.TestFailure)
+		}
+	}
+	return res
+}
+
+// buildPackage returns the package of a build event's import path,
+// which may name a test variant, as in "pkg [pkg.test]".
+func buildPackage(importPath string) string {
+	pkg, _, _ := strings.Cut(importPath, " ")
+	return pkg
+}
+
+// flush returns the events still pending at the end of the stream,
+// in package order.
+func (n *testEventNormalizer) flush() []*TestEvent {
+	var res []*TestEvent
+	for _, name := range slices.Sorted(maps.Keys(n.pkgs)) {
+		if ps := n.pkgs[name]; ps.panic != nil {
+			res = append(res, ps.panic)
+			ps.panic = nil
+		}
+	}
+	for _, path := range slices.Sorted(maps.Keys(n.build)) {
+		res = append(res, n.build[path])
+	}
+	clear(n.build)
+	return res
+}
+
+// readTestEvents reads a go test -json stream from r and calls f with
+// each normalized event. Lines that are not JSON, like errors of the
+// go command itself, become output events with no package.
+func readTestEvents(r io.Reader, f func(*TestEvent) error) error {
+	n := newTestEventNormalizer()
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for s.Scan() {
+		var evs []*TestEvent
+		var ev goTestEvent
+		if err := json.Unmarshal(s.Bytes(), &ev); err != nil || ev.Action == "" {
+			evs = []*TestEvent{{Kind: "output", Output: s.Text() + "\n"}}
+		} else {
+			evs = n.process(&ev)
+		}
+		for _, e := range evs {
+			if err := f(e); err != nil {
+				return err
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	for _, e := range n.flush() {
+		if err := f(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalizeTestEvents reads a go test -json stream from r and writes the
+// normalized events to w, one JSON object per line.
+func normalizeTestEvents(r io.Reader, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	return readTestEvents(r, func(e *TestEvent) error { return enc.Encode(e) })
+}
+
+func runTestEvents(_ []string) error {
+	return normalizeTestEvents(os.Stdin, os.Stdout)
+}
@@ -0,0 +1,181 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// testEventStream is the output of go test -json for a package with a
+// skipped subtest, a failing test and a panicking subtest, a cached
+// package and a package that fails to build.
+const testEventStream = `{"Action":"start","Package":"example.com/pt"}
+{"Action":"run","Package":"example.com/pt","Test":"TestOK"}
+{"Action":"output","Package":"example.com/pt","Test":"TestOK","Output":"=== RUN   TestOK\n","OutputType":"frame"}
+{"Action":"run","Package":"example.com/pt","Test":"TestOK/skip_me"}
+{"Action":"output","Package":"example.com/pt","Test":"TestOK/skip_me","Output":"=== RUN   TestOK/skip_me\n","OutputType":"frame"}
+{"Action":"output","Package":"example.com/pt","Test":"TestOK/skip_me","Output":"    p_test.go:6: not on this OS\n"}
+{"Action":"output","Package":"example.com/pt","Test":"TestOK/skip_me","Output":"--- SKIP: TestOK/skip_me (0.00s)\n","OutputType":"frame"}
+{"Action":"skip","Package":"example.com/pt","Test":"TestOK/skip_me","Elapsed":0}
+{"Action":"output","Package":"example.com/pt","Test":"TestOK","Output":"    p_test.go:7: hello\n"}
+{"Action":"output","Package":"example.com/pt","Test":"TestOK","Output":"--- PASS: TestOK (0.00s)\n","OutputType":"frame"}
+{"Action":"pass","Package":"example.com/pt","Test":"TestOK","Elapsed":0}
+{"Action":"run","Package":"example.com/pt","Test":"TestFail"}
+{"Action":"output","Package":"example.com/pt","Test":"TestFail","Output":"=== RUN   TestFail\n","OutputType":"frame"}
+{"Action":"output","Package":"example.com/pt","Test":"TestFail","Output":"    p_test.go:11: got 1,\n","OutputType":"error"}
+{"Action":"output","Package":"example.com/pt","Test":"TestFail","Output":"        want 2\n","OutputType":"error-continue"}
+{"Action":"output","Package":"example.com/pt","Test":"TestFail","Output":"--- FAIL: TestFail (0.00s)\n","OutputType":"frame"}
+{"Action":"fail","Package":"example.com/pt","Test":"TestFail","Elapsed":0}
+{"Action":"run","Package":"example.com/pt","Test":"TestA"}
+{"Action":"run","Package":"example.com/pt","Test":"TestA/sub"}
+{"Action":"run","Package":"example.com/pt","Test":"TestA/sub/deeper"}
+{"Action":"output","Package":"example.com/pt","Test":"TestA/sub/deeper","Output":"--- FAIL: TestA/sub/deeper (0.00s)\n","OutputType":"frame"}
+{"Action":"fail","Package":"example.com/pt","Test":"TestA/sub/deeper","Elapsed":0}
+{"Action":"output","Package":"example.com/pt","Test":"TestA/sub","Output":"--- FAIL: TestA/sub (0.00s)\n","OutputType":"frame"}
+{"Action":"fail","Package":"example.com/pt","Test":"TestA/sub","Elapsed":0}
+{"Action":"output","Package":"example.com/pt","Test":"TestA","Output":"--- FAIL: TestA (0.00s)\n","OutputType":"frame"}
+{"Action":"output","Package":"example.com/pt","Test":"TestA","Output":"panic: assignment to entry in nil map [recovered, repanicked]\n"}
+{"Action":"output","Package":"example.com/pt","Test":"TestA","Output":"\n"}
+{"Action":"output","Package":"example.com/pt","Test":"TestA","Output":"goroutine 11 [running]:\n"}
+{"Action":"output","Package":"example.com/pt","Test":"TestA","Output":"testing.tRunner.func1.2({0x6b7350, 0x6ef0e0})\n"}
+{"Action":"output","Package":"example.com/pt","Test":"TestA","Output":"\t/usr/local/go/src/testing/testing.go:2123 +0x232\n"}
+{"Action":"output","Package":"example.com/pt","Test":"TestA","Output":"panic({0x6b7350?, 0x6ef0e0?})\n"}
+{"Action":"output","Package":"example.com/pt","Test":"TestA","Output":"\t/usr/local/go/src/runtime/panic.go:859 +0x125\n"}
+{"Action":"output","Package":"example.com/pt","Test":"TestA","Output":"example.com/pt.TestA.func1.1(0x390fb7206d88?)\n"}
+{"Action":"output","Package":"example.com/pt","Test":"TestA","Output":"\t/tmp/pt/p_test.go:18 +0x28\n"}
+{"Action":"output","Package":"example.com/pt","Test":"TestA","Output":"testing.tRunner(0x390fb7206d88, 0x6d4df8)\n"}
+{"Action":"output","Package":"example.com/pt","Test":"TestA","Output":"\t/usr/local/go/src/testing/testing.go:2193 +0xea\n"}
+{"Action":"fail","Package":"example.com/pt","Test":"TestA","Elapsed":0}
+{"Action":"output","Package":"example.com/pt","Output":"FAIL\texample.com/pt\t0.005s\n","OutputType":"frame"}
+{"Action":"fail","Package":"example.com/pt","Elapsed":0.005}
+{"Action":"start","Package":"example.com/pt/ok"}
+{"Action":"output","Package":"example.com/pt/ok","Output":"ok  \texample.com/pt/ok\t(cached)\n"}
+{"Action":"pass","Package":"example.com/pt/ok","Elapsed":0}
+{"ImportPath":"example.com/pt/bad [example.com/pt/bad.test]","Action":"build-output","Output":"# example.com/pt/bad [example.com/pt/bad.test]\n"}
+{"ImportPath":"example.com/pt/bad [example.com/pt/bad.test]","Action":"build-output","Output":"bad/b.go:2:12: undefined: x\n"}
+{"ImportPath":"example.com/pt/bad [example.com/pt/bad.test]","Action":"build-fail"}
+{"Action":"output","Package":"example.com/pt/bad","Output":"FAIL\texample.com/pt/bad [build failed]\n","OutputType":"frame"}
+{"Action":"fail","Package":"example.com/pt/bad","Elapsed":0,"FailedBuild":"example.com/pt/bad [example.com/pt/bad.test]"}
+`
+
+func TestNormalizeTestEvents(t *testing.T) {
+	var out bytes.Buffer
+	if err := normalizeTestEvents(strings.NewReader(testEventStream), &out); err != nil {
+		t.Fatal(err)
+	}
+	var events []*TestEvent
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var e TestEvent
+		if err := dec.Decode(&e); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, &e)
+	}
+	find := func(kind, pkg, test string) *TestEvent {
+		t.Helper()
+		for _, e := range events {
+			if e.Kind == kind && e.Package == pkg && e.Test == test {
+				return e
+			}
+		}
+		t.Fatalf("no %s event for %s %s", kind, pkg, test)
+		return nil
+	}
+
+	for _, e := range events {
+		if strings.HasPrefix(e.Output, "=== ") || strings.Contains(e.Output, "--- ") {
+			t.Errorf("framing output not dropped: %+v", e)
+		}
+	}
+
+	if e := find("skip", "example.com/pt", "TestOK/skip_me"); e.Parent != "TestOK" || e.Name != "skip_me" || e.SkipReason != "not on this OS" {
+		t.Errorf("skip event = %+v, want parent TestOK, name skip_me, reason %q", e, "not on this OS")
+	}
+
+	e := find("fail", "example.com/pt", "TestFail")
+	want := TestFailure{File: "p_test.go", Line: 11, Message: "got 1,\nwant 2"}
+	if len(e.Failures) != 1 || *e.Failures[0] != want {
+		t.Errorf("TestFail failures = %+v, want [%+v]", e.Failures, want)
+	}
+
+	e = find("panic", "example.com/pt", "TestA/sub/deeper")
+	if p := e.Panic; p == nil || p.Message != "assignment to entry in nil map" ||
+		p.Function != "example.com/pt.TestA.func1.1" || p.File != "/tmp/pt/p_test.go" || p.Line != 18 {
+		t.Errorf("panic = %+v, want nil map assignment at /tmp/pt/p_test.go:18 in example.com/pt.TestA.func1.1", e.Panic)
+	}
+	for _, e := range events {
+		if e.Kind == "output" && e.Test == "TestA" {
+			t.Errorf("panic output attributed to TestA: %q", e.Output)
+		}
+	}
+
+	if e := find("pass", "example.com/pt/ok", ""); !e.Cached {
+		t.Errorf("cached package pass event = %+v, want Cached", e)
+	}
+
+	e = find("build-fail", "example.com/pt/bad", "")
+	want = TestFailure{File: "bad/b.go", Line: 2, Col: 12, Message: "undefined: x"}
+	if len(e.Failures) != 1 || *e.Failures[0] != want {
+		t.Errorf("build failures = %+v, want [%+v]", e.Failures, want)
+	}
+	if e := find("fail", "example.com/pt/bad", ""); e.FailedBuild == "" {
+		t.Errorf("package fail event = %+v, want FailedBuild", e)
+	}
+}
+
+// testPanicStream is the output of go test -json for a package whose
+// TestMain panics, followed by two packages cut off in the middle of a
+// panic.
+const testPanicStream = `{"Action":"start","Package":"example.com/m"}
+{"Action":"output","Package":"example.com/m","Output":"panic: boom\n"}
+{"Action":"output","Package":"example.com/m","Output":"\n"}
+{"Action":"output","Package":"example.com/m","Output":"goroutine 1 [running]:\n"}
+{"Action":"output","Package":"example.com/m","Output":"example.com/m.TestMain(0xc000100000)\n"}
+{"Action":"output","Package":"example.com/m","Output":"\t/tmp/m/m_test.go:8 +0x25\n"}
+{"Action":"output","Package":"example.com/m","Output":"exit status 2\n"}
+{"Action":"output","Package":"example.com/m","Output":"FAIL\texample.com/m\t0.004s\n","OutputType":"frame"}
+{"Action":"fail","Package":"example.com/m","Elapsed":0.004}
+{"Action":"start","Package":"example.com/z"}
+{"Action":"output","Package":"example.com/z","Output":"panic: z\n"}
+{"Action":"start","Package":"example.com/a"}
+{"Action":"output","Package":"example.com/a","Output":"panic: a\n"}
+`
+
+func TestNormalizeTestEventsPanicEnd(t *testing.T) {
+	// The pending panics are flushed in package order, whatever the
+	// order of the map they are kept in.
+	for range 10 {
+		var out bytes.Buffer
+		if err := normalizeTestEvents(strings.NewReader(testPanicStream), &out); err != nil {
+			t.Fatal(err)
+		}
+		var panics []*TestEvent
+		dec := json.NewDecoder(&out)
+		for dec.More() {
+			var e TestEvent
+			if err := dec.Decode(&e); err != nil {
+				t.Fatal(err)
+			}
+			if e.Kind == "panic" {
+				panics = append(panics, &e)
+			}
+		}
+		var pkgs []string
+		for _, e := range panics {
+			pkgs = append(pkgs, e.Package)
+		}
+		if want := []string{"example.com/m", "example.com/a", "example.com/z"}; !slices.Equal(pkgs, want) {
+			t.Fatalf("panics in %v, want %v", pkgs, want)
+		}
+		if p := panics[0].Panic; p.Message != "boom" || p.Line != 8 || strings.Contains(p.Stack, "FAIL") || strings.Contains(p.Stack, "exit status") {
+			t.Fatalf("TestMain panic = %+v, want boom at line 8 without the package summary", p)
+		}
+	}
+}
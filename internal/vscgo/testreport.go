@@ -0,0 +1,465 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	testReportFlags   = flag.NewFlagSet("test-report", flag.ExitOnError)
+	testReportFormat  = testReportFlags.String("format", "markdown", "output format: markdown (a summary) or junit (JUnit XML)")
+	testReportSlowest = testReportFlags.Int("slowest", 10, "number of slowest tests to list in the summary")
+)
+
+// testReport is the result of a test run, built from its events.
+type testReport struct {
+	pkgs  []*pkgResult
+	byPkg map[string]*pkgResult
+	other string // output of no package, like errors of the go command
+}
+
+// pkgResult is the result of the tests of one package.
+type pkgResult struct {
+	name        string
+	start       time.Time
+	result      string // pass, fail or skip; "" if the package did not finish
+	elapsed     float64
+	cached      bool
+	buildOutput string     // output of a failed build
+	output      string     // output not attributed to a test
+	panic       *TestPanic // panic outside of a test, as in TestMain or init
+	tests       []*testResult
+	byName      map[string]*testResult
+}
+
+// testResult is the result of one test.
+type testResult struct {
+	name       string
+	result     string // pass, fail or skip; "" if the test did not finish
+	elapsed    float64
+	output     string
+	skipReason string
+	failures   []*TestFailure
+	panic      *TestPanic
+}
+
+func (r *testReport) pkg(name string) *pkgResult {
+	p := r.byPkg[name]
+	if p == nil {
+		p = &pkgResult{name: name, byName: map[string]*testResult{}}
+		r.byPkg[name] = p
+		r.pkgs = append(r.pkgs, p)
+	}
+	return p
+}
+
+func (p *pkgResult) test(name string) *testResult {
+	t := p.byName[name]
+	if t == nil {
+		t = &testResult{name: name}
+		p.byName[name] = t
+		p.tests = append(p.tests, t)
+	}
+	return t
+}
+
+// add records a normalized test event.
+func (r *testReport) add(e *TestEvent) {
+	if e.Package == "" {
+		r.other += e.Output
+		return
+	}
+	p := r.pkg(e.Package)
+	var t *testResult
+	if e.Test != "" {
+		t = p.test(e.Test)
+	}
+	switch e.Kind {
+	case "start":
+		p.start = e.Time
+	case "output":
+		if t != nil {
+			t.output += e.Output
+		} else {
+			p.output += e.Output
+		}
+	case "build-fail":
+		p.buildOutput += e.Output
+	case "panic":
+		if t == nil {
+			// The stack is normally in the package output already,
+			// as output events of no test.
+			p.panic = e.Panic
+			if !strings.Contains(p.output, e.Panic.Stack) {
+				p.output += e.Panic.Stack
+			}
+			break
+		}
+		t.panic = e.Panic
+		t.result = "fail"
+	case "pass", "fail", "skip":
+		if t == nil {
+			p.result, p.elapsed, p.cached = e.Kind, e.Elapsed, e.Cached
+			break
+		}
+		if t.panic == nil {
+			t.result = e.Kind
+		}
+		t.elapsed = e.Elapsed
+		t.skipReason = e.SkipReason
+		t.failures = e.Failures
+	}
+}
+
+// readTestReport builds a report from a go test -json stream.
+func readTestReport(r io.Reader) (*testReport, error) {
+	rep := &testReport{byPkg: map[string]*pkgResult{}}
+	err := readTestEvents(r, func(e *TestEvent) error {
+		rep.add(e)
+		return nil
+	})
+	return rep, err
+}
+
+// counts returns the number of passed, failed and skipped tests of p.
+// Tests that did not finish count as failed.
+func (p *pkgResult) counts() (passed, failed, skipped int) {
+	for _, t := range p.tests {
+		switch t.result {
+		case "pass":
+			passed++
+		case "skip":
+			skipped++
+		default:
+			failed++
+		}
+	}
+	return passed, failed, skipped
+}
+
+// failed reports whether the package failed, which it may do without
+// any failing test, for example when it fails to build or TestMain
+// exits with a non-zero status.
+func (p *pkgResult) failed() bool {
+	return p.result == "fail" || p.result == "" || p.buildOutput != "" || p.panic != nil
+}
+
+// failedLeaves returns the failed tests of p that have no failed subtests:
+// a parent test fails with its subtests, but its failure is theirs.
+func (p *pkgResult) failedLeaves() []*testResult {
+	var res []*testResult
+	for _, t := range p.tests {
+		if t.result == "pass" || t.result == "skip" {
+			continue
+		}
+		leaf := true
+		for _, u := range p.tests {
+			if u.result == "fail" && strings.HasPrefix(u.name, t.name+"/") {
+				leaf = false
+				break
+			}
+		}
+		if leaf || t.output != "" {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+// writeTestSummary writes a Markdown summary of the report: the counts
+// of each package, the n slowest tests and the output of the failures.
+func writeTestSummary(w io.Writer, r *testReport, n int) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# Test report\n\n")
+	fmt.Fprintf(bw, "| Package | Passed | Failed | Skipped | Time |\n")
+	fmt.Fprintf(bw, "| --- | ---: | ---: | ---: | ---: |\n")
+	var total [3]int
+	for _, p := range r.pkgs {
+		passed, failed, skipped := p.counts()
+		total[0] += passed
+		total[1] += failed
+		total[2] += skipped
+		status := ""
+		switch {
+		case p.buildOutput != "":
+			status = " (build failed)"
+		case p.panic != nil:
+			status = " (panicked)"
+		case p.result == "":
+			status = " (did not finish)"
+		case p.result == "skip" && len(p.tests) == 0:
+			status = " (no test files)"
+		case p.cached:
+			status = " (cached)"
+		case p.failed() && failed == 0:
+			status = " (failed)"
+		}
+		fmt.Fprintf(bw, "| %s%s | %d | %d | %d | %.2fs |\n", markdownCell(p.name), status, passed, failed, skipped, p.elapsed)
+	}
+	fmt.Fprintf(bw, "\n**%d passed, %d failed, %d skipped** in %d packages.\n", total[0], total[1], total[2], len(r.pkgs))
+	if r.other != "" {
+		fence := markdownFence(r.other)
+		fmt.Fprintf(bw, "\n## Other output\n\n%s\n%s%s\n", fence, r.other, fence)
+	}
+
+	type timed struct {
+		pkg string
+		*testResult
+	}
+	var tests []timed
+	for _, p := range r.pkgs {
+		for _, t := range p.tests {
+			if t.elapsed > 0 {
+				tests = append(tests, timed{p.name, t})
+			}
+		}
+	}
+	slices.SortStableFunc(tests, func(a, b timed) int { return cmp.Compare(b.elapsed, a.elapsed) })
+	if len(tests) > 0 && n > 0 {
+		fmt.Fprintf(bw, "\n## Slowest tests\n\n")
+		fmt.Fprintf(bw, "| Test | Package | Time |\n")
+		fmt.Fprintf(bw, "| --- | --- | ---: |\n")
+		for _, t := range tests[:min(n, len(tests))] {
+			fmt.Fprintf(bw, "| %s | %s | %.2fs |\n", markdownCell(t.name), markdownCell(t.pkg), t.elapsed)
+		}
+	}
+
+	wroteHeader := false
+	failure := func(title, output string) {
+		if !wroteHeader {
+			fmt.Fprintf(bw, "\n## Failures\n")
+			wroteHeader = true
+		}
+		fmt.Fprintf(bw, "\n### %s\n\n", title)
+		fence := markdownFence(output)
+		fmt.Fprintf(bw, "%s\n%s", fence, output)
+		if !strings.HasSuffix(output, "\n") {
+			fmt.Fprintf(bw, "\n")
+		}
+		fmt.Fprintf(bw, "%s\n", fence)
+	}
+	for _, p := range r.pkgs {
+		if !p.failed() {
+			continue
+		}
+		if p.buildOutput != "" {
+			failure(p.name+" (build failed)", p.buildOutput)
+			continue
+		}
+		leaves := p.failedLeaves()
+		for _, t := range leaves {
+			failure(p.name+"."+t.name, t.output)
+		}
+		if p.panic != nil {
+			failure(p.name+" (panic)", p.output)
+		} else if len(leaves) == 0 || p.result == "" {
+			failure(p.name, p.output)
+		}
+	}
+	return bw.Flush()
+}
+
+// markdownCell escapes s for use in a Markdown table cell.
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+// markdownFence returns a code fence longer than
+// any run of backquotes in s.
+func markdownFence(s string) string {
+	longest, run := 0, 0
+	for _, c := range s {
+		if c == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// JUnit XML, in the form understood by Jenkins, GitLab and most
+// other CI systems. See https://github.com/testmoapp/junitxml.
+type (
+	junitTestSuites struct {
+		XMLName  xml.Name         `xml:"testsuites"`
+		Tests    int              `xml:"tests,attr"`
+		Failures int              `xml:"failures,attr"`
+		Errors   int              `xml:"errors,attr"`
+		Skipped  int              `xml:"skipped,attr"`
+		Time     string           `xml:"time,attr"`
+		Suites   []junitTestSuite `xml:"testsuite"`
+	}
+	junitTestSuite struct {
+		Name      string          `xml:"name,attr"`
+		Tests     int             `xml:"tests,attr"`
+		Failures  int             `xml:"failures,attr"`
+		Errors    int             `xml:"errors,attr"`
+		Skipped   int             `xml:"skipped,attr"`
+		Time      string          `xml:"time,attr"`
+		Timestamp string          `xml:"timestamp,attr,omitempty"`
+		Cases     []junitTestCase `xml:"testcase"`
+		SystemOut *junitText      `xml:"system-out"`
+	}
+	junitTestCase struct {
+		ClassName string        `xml:"classname,attr"`
+		Name      string        `xml:"name,attr"`
+		Time      string        `xml:"time,attr"`
+		Failure   *junitFailure `xml:"failure"`
+		Error     *junitFailure `xml:"error"`
+		Skipped   *junitFailure `xml:"skipped"`
+		SystemOut *junitText    `xml:"system-out"`
+	}
+	junitFailure struct {
+		Message string `xml:"message,attr,omitempty"`
+		Text    string `xml:",cdata"`
+	}
+	junitText struct {
+		Text string `xml:",cdata"`
+	}
+)
+
+// junitOutput returns the system-out element for output, if any.
+func junitOutput(output string) *junitText {
+	if output == "" {
+		return nil
+	}
+	return &junitText{output}
+}
+
+func junitTime(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
+
+// writeJUnit writes the report as JUnit XML, with a test suite per
+// package. A build failure or a panic outside of a test is reported
+// as an error of a test case named "[build]" or "[package]", and a
+// package that otherwise fails without a failing test as a failure of
+// a test case named "[package]". Output of no package goes in the
+// system-out of a test suite named "[other]".
+func writeJUnit(w io.Writer, r *testReport) error {
+	ts := junitTestSuites{}
+	var total float64
+	for _, p := range r.pkgs {
+		s := junitTestSuite{Name: p.name, Time: junitTime(p.elapsed), Cases: []junitTestCase{}}
+		if !p.start.IsZero() {
+			s.Timestamp = p.start.UTC().Format("2006-01-02T15:04:05")
+		}
+		for _, t := range p.tests {
+			c := junitTestCase{ClassName: p.name, Name: t.name, Time: junitTime(t.elapsed)}
+			switch t.result {
+			case "pass":
+				c.SystemOut = junitOutput(t.output)
+			case "skip":
+				s.Skipped++
+				c.Skipped = &junitFailure{Message: t.skipReason}
+				c.SystemOut = junitOutput(t.output)
+			default:
+				s.Failures++
+				msg := "Failed"
+				switch {
+				case t.panic != nil:
+					msg = "panic: " + t.panic.Message
+				case t.result == "":
+					msg = "Did not finish"
+				case len(t.failures) > 0:
+					f := t.failures[0]
+					msg, _, _ = strings.Cut(fmt.Sprintf("%s:%d: %s", f.File, f.Line, f.Message), "\n")
+				}
+				c.Failure = &junitFailure{Message: msg, Text: t.output}
+			}
+			s.Cases = append(s.Cases, c)
+		}
+		switch {
+		case p.buildOutput != "":
+			s.Errors++
+			s.Cases = append(s.Cases, junitTestCase{
+				ClassName: p.name,
+				Name:      "[build]",
+				Time:      junitTime(0),
+				Error:     &junitFailure{Message: "Build failed", Text: p.buildOutput},
+			})
+		case p.panic != nil:
+			s.Errors++
+			s.Cases = append(s.Cases, junitTestCase{
+				ClassName: p.name,
+				Name:      "[package]",
+				Time:      junitTime(p.elapsed),
+				Error:     &junitFailure{Message: "panic: " + p.panic.Message, Text: p.output},
+			})
+		case p.failed() && (s.Failures == 0 || p.result == ""):
+			s.Failures++
+			s.Cases = append(s.Cases, junitTestCase{
+				ClassName: p.name,
+				Name:      "[package]",
+				Time:      junitTime(p.elapsed),
+				Failure:   &junitFailure{Message: "Failed", Text: p.output},
+			})
+		default:
+			s.SystemOut = junitOutput(p.output)
+		}
+		s.Tests = len(s.Cases)
+		ts.Tests += s.Tests
+		ts.Failures += s.Failures
+		ts.Errors += s.Errors
+		ts.Skipped += s.Skipped
+		total += p.elapsed
+		ts.Suites = append(ts.Suites, s)
+	}
+	if r.other != "" {
+		ts.Suites = append(ts.Suites, junitTestSuite{Name: "[other]", Time: junitTime(0), Cases: []junitTestCase{}, SystemOut: junitOutput(r.other)})
+	}
+	ts.Time = junitTime(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	if err := enc.Encode(ts); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func runTestReport(args []string) error {
+	switch *testReportFormat {
+	case "markdown", "junit":
+	default:
+		return fmt.Errorf("unknown format %q, want markdown or junit", *testReportFormat)
+	}
+	var readers []io.Reader
+	for _, name := range args {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		readers = append(readers, f)
+	}
+	if len(readers) == 0 {
+		readers = append(readers, os.Stdin)
+	}
+	r, err := readTestReport(io.MultiReader(readers...))
+	if err != nil {
+		return err
+	}
+	if *testReportFormat == "junit" {
+		return writeJUnit(os.Stdout, r)
+	}
+	return writeTestSummary(os.Stdout, r, *testReportSlowest)
+}
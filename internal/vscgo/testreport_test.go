@@ -0,0 +1,138 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestTestSummary(t *testing.T) {
+	r, err := readTestReport(strings.NewReader(testEventStream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := writeTestSummary(&buf, r, 5); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"| example.com/pt | 1 | 4 | 1 | 0.01s |\n",
+		"| example.com/pt/ok (cached) | 0 | 0 | 0 | 0.00s |\n",
+		"| example.com/pt/bad (build failed) | 0 | 0 | 0 | 0.00s |\n",
+		"**1 passed, 4 failed, 1 skipped** in 3 packages.",
+		"### example.com/pt.TestFail\n\n```\n    p_test.go:11: got 1,\n        want 2\n```\n",
+		"### example.com/pt.TestA/sub/deeper\n\n```\npanic: assignment to entry in nil map",
+		"### example.com/pt/bad (build failed)\n\n```\n# example.com/pt/bad [example.com/pt/bad.test]\nbad/b.go:2:12: undefined: x\n```\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("summary does not contain %q:\n%s", want, got)
+		}
+	}
+	// TestA and TestA/sub only fail because TestA/sub/deeper does.
+	if strings.Contains(got, "### example.com/pt.TestA\n") || strings.Contains(got, "### example.com/pt.TestA/sub\n") {
+		t.Errorf("summary lists the failures of parent tests:\n%s", got)
+	}
+}
+
+func TestJUnit(t *testing.T) {
+	r, err := readTestReport(strings.NewReader(testEventStream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := writeJUnit(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	var ts junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &ts); err != nil {
+		t.Fatalf("parsing JUnit XML: %v\n%s", err, buf.Bytes())
+	}
+	if ts.Tests != 7 || ts.Failures != 4 || ts.Errors != 1 || ts.Skipped != 1 || len(ts.Suites) != 3 {
+		t.Errorf("got %d tests, %d failures, %d errors, %d skipped in %d suites, want 7, 4, 1, 1 in 3",
+			ts.Tests, ts.Failures, ts.Errors, ts.Skipped, len(ts.Suites))
+	}
+	cases := map[string]junitTestCase{}
+	for _, s := range ts.Suites {
+		for _, c := range s.Cases {
+			cases[c.ClassName+"."+c.Name] = c
+		}
+	}
+	if c := cases["example.com/pt.TestFail"]; c.Failure == nil || c.Failure.Message != "p_test.go:11: got 1," || !strings.Contains(c.Failure.Text, "want 2") {
+		t.Errorf("TestFail failure = %+v, want message %q with the test output", c.Failure, "p_test.go:11: got 1,")
+	}
+	if c := cases["example.com/pt.TestA/sub/deeper"]; c.Failure == nil || c.Failure.Message != "panic: assignment to entry in nil map" {
+		t.Errorf("TestA/sub/deeper failure = %+v, want the panic", c.Failure)
+	}
+	if c := cases["example.com/pt.TestOK/skip_me"]; c.Skipped == nil || c.Skipped.Message != "not on this OS" {
+		t.Errorf("TestOK/skip_me skipped = %+v, want the skip reason", c.Skipped)
+	}
+	if c := cases["example.com/pt/bad.[build]"]; c.Error == nil || !strings.Contains(c.Error.Text, "undefined: x") {
+		t.Errorf("build error = %+v, want the build output", c.Error)
+	}
+}
+
+func TestTestReportPackagePanic(t *testing.T) {
+	// A panic in TestMain, and a line of the go command.
+	stream := "go: downloading example.com/x v1.0.0\n" + testPanicStream
+	r, err := readTestReport(strings.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range r.pkgs {
+		if p.name == "" {
+			t.Errorf("report has a package with no name: %+v", p)
+		}
+	}
+	p := r.byPkg["example.com/m"]
+	if p == nil || p.panic == nil || !p.failed() || strings.Count(p.output, "panic: boom") != 1 {
+		t.Fatalf("example.com/m = %+v, want a failed package with the panic in its output once", p)
+	}
+
+	var buf bytes.Buffer
+	if err := writeTestSummary(&buf, r, 5); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"| example.com/m (panicked) | 0 | 0 | 0 | 0.00s |\n",
+		"## Other output\n\n```\ngo: downloading example.com/x v1.0.0\n```\n",
+		"### example.com/m (panic)\n\n```\npanic: boom\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("summary does not contain %q:\n%s", want, got)
+		}
+	}
+
+	buf.Reset()
+	if err := writeJUnit(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	var ts junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &ts); err != nil {
+		t.Fatalf("parsing JUnit XML: %v\n%s", err, buf.Bytes())
+	}
+	var other *junitText
+	var pkgErr *junitFailure
+	for _, s := range ts.Suites {
+		if s.Name == "[other]" {
+			other = s.SystemOut
+		}
+		for _, c := range s.Cases {
+			if c.ClassName == "example.com/m" && c.Name == "[package]" {
+				pkgErr = c.Error
+			}
+		}
+	}
+	if pkgErr == nil || pkgErr.Message != "panic: boom" {
+		t.Errorf("example.com/m [package] error = %+v, want the panic", pkgErr)
+	}
+	if other == nil || !strings.Contains(other.Text, "go: downloading") {
+		t.Errorf("[other] system-out = %+v, want the go command output", other)
+	}
+}
@@ -0,0 +1,268 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	testRunFlags   = flag.NewFlagSet("test", flag.ExitOnError)
+	testRunDir     = testRunFlags.String("C", "", "directory to run go test in (default: current directory)")
+	testRunP       = testRunFlags.Int("p", runtime.NumCPU(), "number of packages to test in parallel")
+	testRunTimeout = testRunFlags.Duration("timeout", 10*time.Minute, "timeout of the tests of each package, as go test's -timeout; 0 disables it")
+	testRunShard   = testRunFlags.String("shard", "", "test only shard i of n shards of the packages, as i/n with 0 <= i < n")
+	testRunRerun   = testRunFlags.Int("rerun", 0, "number of times to rerun failed tests")
+)
+
+// testRunner runs the tests of packages with go test -json, and writes
+// their events to a single stream.
+type testRunner struct {
+	dir     string
+	goFlags []string // extra go test flags
+	timeout time.Duration
+	rerun   int
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// testPackages returns the import paths of the packages matching
+// patterns that have test files, as reported by go list run in dir.
+func testPackages(ctx context.Context, dir string, patterns []string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "go", append([]string{"list", "-f", "{{if or .TestGoFiles .XTestGoFiles}}{{.ImportPath}}{{end}}", "--"}, patterns...)...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list: %v: %s", err, stderr.Bytes())
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// parseShard parses a shard specification of the form i/n.
+func parseShard(s string) (i, n int, err error) {
+	is, ns, ok := strings.Cut(s, "/")
+	if ok {
+		i, err = strconv.Atoi(is)
+		if err == nil {
+			n, err = strconv.Atoi(ns)
+		}
+	}
+	if !ok || err != nil || n < 1 || i < 0 || i >= n {
+		return 0, 0, fmt.Errorf("invalid shard %q, want i/n with 0 <= i < n", s)
+	}
+	return i, n, nil
+}
+
+// shardPackages returns the packages of shard i of n. Packages are
+// assigned to shards by a hash of their import path, so that adding
+// or removing a package does not move the others to other shards.
+func shardPackages(pkgs []string, i, n int) []string {
+	var res []string
+	for _, pkg := range pkgs {
+		h := fnv.New32a()
+		io.WriteString(h, pkg)
+		if int(h.Sum32()%uint32(n)) == i {
+			res = append(res, pkg)
+		}
+	}
+	return res
+}
+
+// write writes an event line of the merged stream. Events of reruns
+// are marked with the number of the rerun in a Rerun field.
+func (r *testRunner) write(line []byte, rerun int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if rerun > 0 && len(line) > 0 && line[0] == '{' {
+		if _, err := fmt.Fprintf(r.w, `{"Rerun":%d,`, rerun); err != nil {
+			return err
+		}
+		line = line[1:]
+	}
+	if _, err := r.w.Write(line); err != nil {
+		return err
+	}
+	_, err := r.w.Write([]byte("\n"))
+	return err
+}
+
+// runPackage runs the tests of pkg matching run, if not empty, and
+// writes their events. It reports the failed tests that have no failed
+// subtests, and whether the package failed.
+func (r *testRunner) runPackage(ctx context.Context, pkg, run string, rerun int) (failed []string, pkgFailed bool, _ error) {
+	args := []string{"test", "-json"}
+	if r.timeout > 0 {
+		args = append(args, "-timeout="+r.timeout.String())
+	}
+	args = append(args, r.goFlags...)
+	if run != "" {
+		// Do not let a cached result of an earlier run with the
+		// same flags stand in for running the failed tests again.
+		args = append(args, "-count=1", "-run="+run)
+	}
+	cmd := exec.CommandContext(ctx, "go", append(args, pkg)...)
+	cmd.Dir = r.dir
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, false, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, false, err
+	}
+
+	var failedTests []string
+	result := ""
+	s := bufio.NewScanner(stdout)
+	s.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for s.Scan() {
+		var ev goTestEvent
+		if json.Unmarshal(s.Bytes(), &ev) == nil && ev.Package == pkg {
+			switch {
+			case ev.Action == "fail" && ev.Test != "":
+				failedTests = append(failedTests, ev.Test)
+			case (ev.Action == "pass" || ev.Action == "fail" || ev.Action == "skip") && ev.Test == "":
+				result = ev.Action
+			}
+		}
+		if err := r.write(s.Bytes(), rerun); err != nil {
+			cmd.Process.Kill()
+			cmd.Wait()
+			return nil, false, err
+		}
+	}
+	err = cmd.Wait()
+	if err := s.Err(); err != nil {
+		return nil, false, err
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, false, err
+	}
+	if ctx.Err() != nil {
+		return nil, false, ctx.Err()
+	}
+
+	for _, t := range failedTests {
+		if !slices.ContainsFunc(failedTests, func(u string) bool { return strings.HasPrefix(u, t+"/") }) {
+			failed = append(failed, t)
+		}
+	}
+	return failed, result != "pass" && result != "skip", nil
+}
+
+// testPackage runs the tests of pkg, and reruns those that fail up to
+// r.rerun times. It reports whether the package finally failed.
+func (r *testRunner) testPackage(ctx context.Context, pkg string) (bool, error) {
+	failed, pkgFailed, err := r.runPackage(ctx, pkg, "", 0)
+	for i := 1; i <= r.rerun && pkgFailed && len(failed) > 0 && err == nil; i++ {
+		// Only failed tests are rerun: if the package failed without
+		// a failed test, as when it does not build, rerunning it
+		// would fail again.
+		failed, pkgFailed, err = r.runPackage(ctx, pkg, testPathsPattern(failed), i)
+	}
+	return pkgFailed, err
+}
+
+// runTests tests the packages, up to parallel at a time, and returns
+// the packages that failed.
+func (r *testRunner) runTests(ctx context.Context, pkgs []string, parallel int) ([]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	failed := make([]bool, len(pkgs))
+	errs := make([]error, len(pkgs))
+	sem := make(chan struct{}, max(parallel, 1))
+	var wg sync.WaitGroup
+	for i, pkg := range pkgs {
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if ctx.Err() != nil {
+				return
+			}
+			failed[i], errs[i] = r.testPackage(ctx, pkg)
+			if errs[i] != nil {
+				cancel()
+			}
+		})
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	var res []string
+	for i, pkg := range pkgs {
+		if failed[i] {
+			res = append(res, pkg)
+		}
+	}
+	return res, nil
+}
+
+func runTest(args []string) error {
+	// Package patterns never start with a dash: the go test flags
+	// start at the first argument that does, or after "--".
+	i := slices.IndexFunc(args, func(a string) bool { return strings.HasPrefix(a, "-") })
+	if i < 0 {
+		i = len(args)
+	}
+	patterns, goFlags := args[:i], args[i:]
+	if len(goFlags) > 0 && goFlags[0] == "--" {
+		goFlags = goFlags[1:]
+	}
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	pkgs, err := testPackages(ctx, *testRunDir, patterns)
+	if err != nil {
+		return err
+	}
+	if *testRunShard != "" {
+		i, n, err := parseShard(*testRunShard)
+		if err != nil {
+			return err
+		}
+		pkgs = shardPackages(pkgs, i, n)
+	}
+
+	r := &testRunner{
+		dir:     *testRunDir,
+		goFlags: goFlags,
+		timeout: *testRunTimeout,
+		rerun:   *testRunRerun,
+		w:       os.Stdout,
+	}
+	failed, err := r.runTests(ctx, pkgs, *testRunP)
+	if err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d packages failed: %s", len(failed), len(pkgs), strings.Join(failed, " "))
+	}
+	return nil
+}
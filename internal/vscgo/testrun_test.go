@@ -0,0 +1,144 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestShardPackages(t *testing.T) {
+	var pkgs []string
+	for i := range 100 {
+		pkgs = append(pkgs, fmt.Sprintf("example.com/m/p%d", i))
+	}
+	var all []string
+	for i := range 4 {
+		shard := shardPackages(pkgs, i, 4)
+		if len(shard) == 0 {
+			t.Errorf("shard %d/4 is empty", i)
+		}
+		all = append(all, shard...)
+	}
+	slices.Sort(all)
+	slices.Sort(pkgs)
+	if !slices.Equal(all, pkgs) {
+		t.Errorf("shards do not partition the packages: got %d packages, want %d", len(all), len(pkgs))
+	}
+
+	// Adding a package does not move the others.
+	before := shardPackages(pkgs, 1, 4)
+	after := shardPackages(append(pkgs, "example.com/m/new"), 1, 4)
+	after = slices.DeleteFunc(after, func(p string) bool { return p == "example.com/m/new" })
+	if !slices.Equal(before, after) {
+		t.Errorf("adding a package changed shard 1/4")
+	}
+
+	for _, s := range []string{"1", "4/4", "-1/2", "a/b", "0/0"} {
+		if _, _, err := parseShard(s); err == nil {
+			t.Errorf("parseShard(%q) succeeded", s)
+		}
+	}
+}
+
+func TestRunTests(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds and runs tests")
+	}
+	marker := t.TempDir()
+	dir := writeFiles(t, t.TempDir(), map[string]string{
+		"go.mod": "module example.com/m\n\ngo 1.21\n",
+		"ok/ok_test.go": `package ok
+
+import "testing"
+
+func TestOK(t *testing.T) {}
+`,
+		"flaky/flaky_test.go": fmt.Sprintf(`package flaky
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFlaky(t *testing.T) {
+	t.Run("sub", func(t *testing.T) {
+		// Fail the first time only.
+		f := filepath.Join(%q, "ran")
+		if _, err := os.Stat(f); err != nil {
+			os.WriteFile(f, nil, 0666)
+			t.Fatal("flaked")
+		}
+	})
+}
+
+func TestStable(t *testing.T) {}
+`, marker),
+		"broken/broken_test.go": `package broken
+
+import "testing"
+
+func TestBroken(t *testing.T) { t.Fatal("broken") }
+`,
+		"none/none.go": "package none\n",
+	})
+	ctx := context.Background()
+	pkgs, err := testPackages(ctx, dir, []string{"./..."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"example.com/m/broken", "example.com/m/flaky", "example.com/m/ok"}; !slices.Equal(pkgs, want) {
+		t.Fatalf("packages = %q, want %q", pkgs, want)
+	}
+
+	var buf bytes.Buffer
+	r := &testRunner{dir: dir, goFlags: []string{"-count=1"}, rerun: 2, w: &buf}
+	failed, err := r.runTests(ctx, pkgs, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"example.com/m/broken"}; !slices.Equal(failed, want) {
+		t.Errorf("failed packages = %q, want %q", failed, want)
+	}
+
+	type event struct {
+		Rerun   int
+		Action  string
+		Package string
+		Test    string
+	}
+	var results []string
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var e event
+		if err := dec.Decode(&e); err != nil {
+			t.Fatal(err)
+		}
+		if e.Action == "pass" || e.Action == "fail" {
+			results = append(results, fmt.Sprintf("%d %s %s %s", e.Rerun, strings.TrimPrefix(e.Package, "example.com/m/"), e.Test, e.Action))
+		}
+	}
+	for _, want := range []string{
+		"0 flaky TestFlaky/sub fail",
+		"0 flaky TestStable pass",
+		"1 flaky TestFlaky/sub pass",
+		"1 flaky  pass",
+		"2 broken TestBroken fail",
+		"2 broken  fail",
+	} {
+		if !slices.Contains(results, want) {
+			t.Errorf("no event %q in %q", want, results)
+		}
+	}
+	if slices.Contains(results, "1 flaky TestStable pass") || slices.Contains(results, "2 flaky TestFlaky/sub pass") {
+		t.Errorf("passing tests were rerun: %q", results)
+	}
+}
@@ -0,0 +1,377 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/version"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+var (
+	toolchainFlags = flag.NewFlagSet("toolchain", flag.ExitOnError)
+	toolchainDir   = toolchainFlags.String("C", ".", "directory to resolve the toolchain for")
+)
+
+// ToolchainResolution explains which Go toolchain the go command runs
+// in a directory, following its toolchain selection rules
+// (https://go.dev/doc/toolchain).
+type ToolchainResolution struct {
+	Dir         string
+	Local       string // the version of the go command in PATH
+	GOTOOLCHAIN string
+	Mode        string `json:",omitempty"` // auto or path if go.mod and go.work may select a toolchain
+	File        string `json:",omitempty"` // the go.work or go.mod file consulted
+	GoVersion   string `json:",omitempty"` // the go line of File
+	Toolchain   string `json:",omitempty"` // the toolchain line of File
+	// Selected is the selected toolchain: local, or a toolchain name
+	// such as go1.22.1, and Version its Go version.
+	Selected string
+	Version  string
+	// Executable is the go command of the selected toolchain, unset if
+	// it is not available locally, in which case Download reports
+	// whether the go command would download it.
+	Executable string `json:",omitempty"`
+	Download   bool
+	Error      string           `json:",omitempty"`
+	Steps      []*ToolchainStep // the explanation of the selection
+}
+
+// ToolchainStep is a step of the toolchain selection.
+type ToolchainStep struct {
+	Step   string // GOTOOLCHAIN, go.work, go.mod, go line, toolchain line, select or lookup
+	Source string `json:",omitempty"` // the variable or file the step reads
+	Value  string `json:",omitempty"`
+	Note   string `json:",omitempty"`
+}
+
+// toolchainEnv is the environment the toolchain is resolved in.
+type toolchainEnv struct {
+	getenv     func(string) string
+	lookPath   func(string) (string, error)
+	configDir  string // the user configuration directory, for the default GOENV
+	local      string // the version of the local toolchain, such as go1.22.1
+	goroot     string // the GOROOT of the local toolchain
+	gomodcache string
+	goos       string
+	goarch     string
+}
+
+// config returns the value of the go command configuration variable
+// key, from the environment, the GOENV file, or the go.env file of the
+// local toolchain, and where it was found.
+func (env *toolchainEnv) config(key string) (value, source string) {
+	if v := env.getenv(key); v != "" {
+		return v, "environment"
+	}
+	goenv := env.getenv("GOENV")
+	if goenv == "" && env.configDir != "" {
+		goenv = filepath.Join(env.configDir, "go", "env")
+	}
+	files := []string{filepath.Join(env.goroot, "go.env")}
+	if goenv != "" && goenv != "off" {
+		files = append([]string{goenv}, files...)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		for line := range strings.Lines(string(data)) {
+			k, v, ok := strings.Cut(strings.TrimSpace(line), "=")
+			if ok && k == key && v != "" {
+				return v, file
+			}
+		}
+	}
+	return "", ""
+}
+
+// fromToolchain returns the Go version of a toolchain name, such as
+// go1.22.1 or mycorp-go1.22.1, as the go command does, or "" if the
+// name is invalid.
+func fromToolchain(name string) string {
+	if strings.ContainsAny(name, `\/`) {
+		return ""
+	}
+	var v string
+	if strings.HasPrefix(name, "go") {
+		v = name[2:]
+	} else if i := strings.Index(name, "-go"); i >= 0 {
+		v = name[i+3:]
+	} else {
+		return ""
+	}
+	// Custom builds may have suffixes, as in go1.22.1-mycorp.
+	if i := strings.IndexAny(v, " \t-"); i >= 0 {
+		v = v[:i]
+	}
+	if !version.IsValid("go" + v) {
+		return ""
+	}
+	return v
+}
+
+// findUp returns the file name in dir or its closest ancestor.
+func findUp(dir, name string) string {
+	for {
+		if fi, err := os.Stat(filepath.Join(dir, name)); err == nil && !fi.IsDir() {
+			return filepath.Join(dir, name)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// goModLookup returns the value of the first line of a go.mod or
+// go.work file with the verb key, such as go. Like the go command when
+// it selects a toolchain, it does not parse the rest of the file, which
+// may use directives this version of x/mod does not know.
+func goModLookup(data []byte, key string) string {
+	for line := range strings.Lines(string(data)) {
+		v, ok := strings.CutPrefix(strings.TrimSpace(line), key)
+		if !ok || v == "" || v[0] != ' ' && v[0] != '\t' {
+			continue
+		}
+		v, _, _ = strings.Cut(v, "//")
+		return strings.TrimSpace(v)
+	}
+	return ""
+}
+
+// goToolchainLines returns the file whose go and toolchain lines apply
+// in dir: go.work if in a workspace, and go.mod otherwise.
+func (env *toolchainEnv) goToolchainLines(r *ToolchainResolution, dir string) (file, goVers, toolchain string, err error) {
+	step := func(s *ToolchainStep) { r.Steps = append(r.Steps, s) }
+	gowork, source := env.config("GOWORK")
+	switch gowork {
+	case "off":
+		step(&ToolchainStep{Step: "go.work", Source: source, Value: gowork, Note: "workspace mode is off"})
+	case "", "auto":
+		file = findUp(dir, "go.work")
+	default:
+		file = gowork
+		if !filepath.IsAbs(file) {
+			return "", "", "", fmt.Errorf("GOWORK must be an absolute path: %s", gowork)
+		}
+		// GOWORK may name a file that does not exist yet, as for go
+		// work init, in which case go.mod applies.
+		if _, err := os.Stat(file); err != nil {
+			step(&ToolchainStep{Step: "go.work", Source: source, Value: gowork, Note: "the workspace file set by GOWORK does not exist"})
+			file = ""
+		} else {
+			step(&ToolchainStep{Step: "go.work", Source: source, Value: gowork, Note: "workspace file set by GOWORK"})
+		}
+	}
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", "", "", err
+		}
+		goVers, toolchain = goModLookup(data, "go"), goModLookup(data, "toolchain")
+		step(&ToolchainStep{Step: "go.work", Source: file, Note: "in a workspace: its go and toolchain lines apply, not those of go.mod files"})
+		return file, goVers, toolchain, nil
+	}
+
+	if file = findUp(dir, "go.mod"); file == "" {
+		step(&ToolchainStep{Step: "go.mod", Note: "not in a module"})
+		return "", "", "", nil
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", "", "", err
+	}
+	goVers, toolchain = goModLookup(data, "go"), goModLookup(data, "toolchain")
+	step(&ToolchainStep{Step: "go.mod", Source: file})
+	return file, goVers, toolchain, nil
+}
+
+// moduleMode reports whether module mode is enabled in dir, as
+// cmd/go/internal/modload.WillBeEnabled does, and if it is not, why.
+func (env *toolchainEnv) moduleMode(dir string) (enabled bool, why string) {
+	v, _ := env.config("GO111MODULE")
+	switch v {
+	case "", "on":
+		return true, ""
+	case "auto":
+		if findUp(dir, "go.mod") != "" || findUp(dir, "go.work") != "" {
+			return true, ""
+		}
+		return false, "GO111MODULE=auto and not in a module or workspace"
+	}
+	return false, "GO111MODULE=" + v
+}
+
+// resolveToolchain returns the toolchain the go command selects in dir.
+// It follows the selection of cmd/go/internal/toolchain.
+func (env *toolchainEnv) resolveToolchain(dir string) *ToolchainResolution {
+	r := &ToolchainResolution{Dir: dir, Local: env.local}
+	step := func(s *ToolchainStep) { r.Steps = append(r.Steps, s) }
+	fail := func(err error) *ToolchainResolution {
+		r.Error = err.Error()
+		return r
+	}
+	// The go command compares versions with that of the local
+	// toolchain without suffixes, as in go1.22.1 X:boringcrypto.
+	localVers := strings.TrimPrefix(goVersionOf(env.local), "go")
+
+	gotoolchain, source := env.config("GOTOOLCHAIN")
+	if gotoolchain == "" {
+		// A toolchain built without go.env defaults to local.
+		gotoolchain, source = "local", "default"
+	}
+	r.GOTOOLCHAIN = gotoolchain
+	if ok, why := env.moduleMode(dir); !ok {
+		// The go command only switches toolchains in module mode.
+		step(&ToolchainStep{Step: "GOTOOLCHAIN", Source: source, Value: gotoolchain, Note: "ignored: module mode is off (" + why + ")"})
+		r.Selected, r.Version = "local", "go"+localVers
+		r.Executable = filepath.Join(env.goroot, "bin", "go"+exeSuffix())
+		step(&ToolchainStep{Step: "lookup", Value: r.Executable, Note: "the local toolchain runs"})
+		return r
+	}
+	step(&ToolchainStep{Step: "GOTOOLCHAIN", Source: source, Value: gotoolchain})
+
+	minToolchain, mode, _ := strings.Cut(gotoolchain, "+")
+	switch gotoolchain {
+	case "auto", "path":
+		minToolchain, mode = "local", gotoolchain
+	}
+	if mode != "" && mode != "auto" && mode != "path" {
+		return fail(fmt.Errorf("invalid GOTOOLCHAIN %q", gotoolchain))
+	}
+	minVers := localVers
+	if minToolchain != "local" {
+		if minVers = fromToolchain(minToolchain); minVers == "" {
+			return fail(fmt.Errorf("invalid GOTOOLCHAIN %q", gotoolchain))
+		}
+	}
+	selected := minToolchain
+	if mode == "" {
+		note := "the local toolchain is used, whatever go.mod and go.work require"
+		if selected != "local" {
+			note = "this toolchain is used, whatever go.mod and go.work require"
+		}
+		step(&ToolchainStep{Step: "select", Value: selected, Note: note})
+	} else {
+		r.Mode = mode
+		step(&ToolchainStep{Step: "select", Value: minToolchain, Note: fmt.Sprintf("minimum toolchain, go %s; go.mod and go.work may select a newer one", minVers)})
+	}
+
+	file, goVers, toolchain, err := env.goToolchainLines(r, dir)
+	if err != nil {
+		return fail(err)
+	}
+	r.File, r.GoVersion, r.Toolchain = file, goVers, toolchain
+	if goVers != "" {
+		step(&ToolchainStep{Step: "go line", Source: file, Value: goVers})
+	}
+	if toolchain != "" {
+		step(&ToolchainStep{Step: "toolchain line", Source: file, Value: toolchain})
+	}
+	if mode != "" {
+		if toolchain == "default" {
+			// As in cmd/go, toolchain default runs the minimum
+			// toolchain, even if the go line requires a newer one.
+			step(&ToolchainStep{Step: "select", Source: file, Value: selected, Note: "toolchain default: the go line does not select a newer toolchain"})
+		} else {
+			if toolchain != "" {
+				tv := fromToolchain(toolchain)
+				if tv == "" || !strings.HasPrefix(toolchain, "go") && !strings.Contains(toolchain, "-go") {
+					return fail(fmt.Errorf("invalid toolchain %q in %s", toolchain, file))
+				}
+				if version.Compare("go"+tv, "go"+minVers) > 0 {
+					selected, minVers = toolchain, tv
+					step(&ToolchainStep{Step: "select", Source: file, Value: selected, Note: "the toolchain line is newer than the minimum toolchain"})
+				}
+			}
+			if goVers != "" && version.Compare("go"+goVers, "go"+minVers) > 0 {
+				// Since Go 1.21, the first release of a language
+				// version has a .0 patch version.
+				selected = toolchainFor("go"+minVers, goVers)
+				minVers = strings.TrimPrefix(selected, "go")
+				step(&ToolchainStep{Step: "select", Source: file, Value: selected, Note: "the go line requires a newer toolchain"})
+			}
+		}
+	}
+
+	if selected == "local" || selected == "go"+localVers {
+		r.Selected, r.Version = "local", "go"+localVers
+	} else {
+		r.Selected, r.Version = selected, "go"+fromToolchain(selected)
+	}
+	if goVers != "" && version.Compare("go"+goVers, r.Version) > 0 {
+		// Only possible if GOTOOLCHAIN does not allow switching, or
+		// the toolchain line is default.
+		r.Error = fmt.Sprintf("%s requires go >= %s (running go %s; GOTOOLCHAIN=%s)", file, goVers, strings.TrimPrefix(r.Version, "go"), gotoolchain)
+	}
+	if r.Selected == "local" {
+		r.Executable = filepath.Join(env.goroot, "bin", "go"+exeSuffix())
+		step(&ToolchainStep{Step: "lookup", Value: r.Executable, Note: "the local toolchain runs"})
+		return r
+	}
+
+	// The go command looks for the toolchain in PATH, as installed by
+	// golang.org/dl wrappers, before downloading it to the module cache.
+	if exe, err := env.lookPath(selected); err == nil {
+		r.Executable = exe
+		step(&ToolchainStep{Step: "lookup", Source: "PATH", Value: exe})
+		return r
+	}
+	if mode == "path" {
+		return fail(fmt.Errorf("cannot find %q in PATH", selected))
+	}
+	modDir := filepath.Join(env.gomodcache, "golang.org", "toolchain@v0.0.1-"+selected+"."+env.goos+"-"+env.goarch)
+	exe := filepath.Join(modDir, "bin", "go"+exeSuffix())
+	if _, err := os.Stat(exe); err == nil {
+		r.Executable = exe
+		step(&ToolchainStep{Step: "lookup", Source: "GOMODCACHE", Value: exe})
+		return r
+	}
+	r.Download = true
+	step(&ToolchainStep{Step: "lookup", Source: "GOMODCACHE", Value: modDir, Note: "not in PATH or the module cache: the go command downloads it"})
+	return r
+}
+
+func runToolchain(_ []string) error {
+	dir, err := filepath.Abs(*toolchainDir)
+	if err != nil {
+		return err
+	}
+	// The local toolchain is the go command in PATH, before it switches
+	// to another toolchain.
+	out, err := goCommand(context.Background(), dir, []string{"GOTOOLCHAIN=local"}, "env", "GOVERSION", "GOROOT", "GOMODCACHE")
+	if err != nil {
+		return fmt.Errorf("go env: %v: %s", err, out)
+	}
+	lines := strings.Split(string(out), "\n")
+	if len(lines) < 3 {
+		return fmt.Errorf("unexpected go env output: %s", out)
+	}
+	configDir, err := os.UserConfigDir()
+	if err != nil && os.Getenv("GOENV") == "" {
+		return fmt.Errorf("cannot locate the GOENV file: %v", err)
+	}
+	env := &toolchainEnv{
+		getenv:     os.Getenv,
+		lookPath:   exec.LookPath,
+		configDir:  configDir,
+		local:      lines[0],
+		goroot:     lines[1],
+		gomodcache: lines[2],
+		goos:       runtime.GOOS,
+		goarch:     runtime.GOARCH,
+	}
+	return json.NewEncoder(os.Stdout).Encode(env.resolveToolchain(dir))
+}
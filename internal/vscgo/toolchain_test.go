@@ -0,0 +1,127 @@
+// Copyright 2026 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vscgo
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestFromToolchain(t *testing.T) {
+	for name, want := range map[string]string{
+		"go1.22.1":           "1.22.1",
+		"go1.23rc1":          "1.23rc1",
+		"go1.22.1-mycorp":    "1.22.1",
+		"mycorp-go1.22.1":    "1.22.1",
+		"go1.22.1 devel":     "1.22.1",
+		"local":              "",
+		"go1.x":              "",
+		"../go1.22.1/bin/go": "",
+	} {
+		if got := fromToolchain(name); got != want {
+			t.Errorf("fromToolchain(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestResolveToolchain(t *testing.T) {
+	root := writeFiles(t, t.TempDir(), map[string]string{
+		"goroot/go.env":     "# defaults\nGOTOOLCHAIN=auto\n",
+		"config/go/env":     "GOPROXY=off\n",
+		"localenv":          "GOTOOLCHAIN=local\n",
+		"old/go.mod":        "module old\n\ngo 1.21.0\n",
+		"new/go.mod":        "module new\n\ngo 1.23\n",
+		"pinned/go.mod":     "module pinned\n\ngo 1.21\n\ntoolchain go1.22.5\n",
+		"unknown/go.mod":    "module unknown\n\ngo 1.99 // a comment\n\nfuture directive\n",
+		"default/go.mod":    "module default\n\ngo 1.21\n\ntoolchain default\n",
+		"defaultnew/go.mod": "module defaultnew\n\ngo 1.23\n\ntoolchain default\n",
+		"ws/go.work":        "go 1.22.3\n\nuse ./a\n",
+		"ws/a/go.mod":       "module a\n\ngo 1.21\n",
+		"modcache/golang.org/toolchain@v0.0.1-go1.22.5.linux-amd64/bin/go": "",
+	})
+	for _, test := range []struct {
+		name       string
+		dir        string
+		local      string // the local toolchain, go1.22.1 if unset
+		env        map[string]string
+		path       map[string]string // executables in PATH
+		selected   string
+		executable string
+		download   bool
+		wantErr    bool
+	}{
+		{name: "local is recent enough", dir: "old", selected: "local", executable: "goroot/bin/go"},
+		{name: "go line", dir: "new", selected: "go1.23.0", download: true},
+		{name: "toolchain line", dir: "pinned", selected: "go1.22.5", executable: "modcache/golang.org/toolchain@v0.0.1-go1.22.5.linux-amd64/bin/go"},
+		{name: "unknown directive", dir: "unknown", selected: "go1.99.0", download: true},
+		{name: "toolchain default", dir: "default", selected: "local", executable: "goroot/bin/go"},
+		{name: "toolchain default with newer go line", dir: "defaultnew", selected: "local", executable: "goroot/bin/go", wantErr: true},
+		{name: "suffixed local version", dir: "old", local: "go1.22.1 X:boringcrypto", selected: "local", executable: "goroot/bin/go"},
+		{name: "suffixed local version, go line", dir: "new", local: "go1.22.1 X:boringcrypto", selected: "go1.23.0", download: true},
+		{name: "devel local version", dir: "new", local: "devel go1.23-abcdef Mon Jan 2 15:04:05 2026 +0000", selected: "local", executable: "goroot/bin/go"},
+		{name: "in PATH", dir: "new", path: map[string]string{"go1.23.0": "/bin/go1.23.0"}, selected: "go1.23.0", executable: "/bin/go1.23.0"},
+		{name: "workspace", dir: "ws/a", selected: "go1.22.3", download: true},
+		{name: "workspace off", dir: "ws/a", env: map[string]string{"GOWORK": "off"}, selected: "local", executable: "goroot/bin/go"},
+		{name: "workspace auto", dir: "ws/a", env: map[string]string{"GOWORK": "auto"}, selected: "go1.22.3", download: true},
+		{name: "workspace file missing", dir: "new", env: map[string]string{"GOWORK": "missing.work"}, selected: "go1.23.0", download: true},
+		{name: "module mode off", dir: "new", env: map[string]string{"GO111MODULE": "off"}, selected: "local", executable: "goroot/bin/go"},
+		{name: "module mode off with GOTOOLCHAIN", dir: "new", env: map[string]string{"GO111MODULE": "off", "GOTOOLCHAIN": "go1.21.0"}, selected: "local", executable: "goroot/bin/go"},
+		{name: "module mode auto outside a module", dir: "config", env: map[string]string{"GO111MODULE": "auto", "GOTOOLCHAIN": "go1.21.0"}, selected: "local", executable: "goroot/bin/go"},
+		{name: "module mode auto in a module", dir: "new", env: map[string]string{"GO111MODULE": "auto", "GOTOOLCHAIN": "go1.21.0"}, path: map[string]string{"go1.21.0": "/bin/go1.21.0"}, selected: "go1.21.0", executable: "/bin/go1.21.0", wantErr: true},
+		{name: "GOENV local", dir: "new", env: map[string]string{"GOENV": "localenv"}, selected: "local", executable: "goroot/bin/go", wantErr: true},
+		{name: "GOTOOLCHAIN name", dir: "new", env: map[string]string{"GOTOOLCHAIN": "go1.21.0"}, path: map[string]string{"go1.21.0": "/bin/go1.21.0"}, selected: "go1.21.0", executable: "/bin/go1.21.0", wantErr: true},
+		{name: "GOTOOLCHAIN minimum", dir: "old", env: map[string]string{"GOTOOLCHAIN": "go1.24.0+auto"}, selected: "go1.24.0", download: true},
+		{name: "GOTOOLCHAIN path", dir: "new", env: map[string]string{"GOTOOLCHAIN": "path"}, selected: "go1.23.0", wantErr: true},
+		{name: "invalid GOTOOLCHAIN", dir: "old", env: map[string]string{"GOTOOLCHAIN": "go1.x+auto"}, wantErr: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			local := test.local
+			if local == "" {
+				local = "go1.22.1"
+			}
+			env := &toolchainEnv{
+				getenv: func(key string) string {
+					v := test.env[key]
+					if (key == "GOENV" || key == "GOWORK" && v != "off" && v != "auto") && v != "" {
+						v = filepath.Join(root, v)
+					}
+					return v
+				},
+				lookPath: func(name string) (string, error) {
+					if exe, ok := test.path[name]; ok {
+						return exe, nil
+					}
+					return "", errors.New("not found")
+				},
+				configDir:  filepath.Join(root, "config"),
+				local:      local,
+				goroot:     filepath.Join(root, "goroot"),
+				gomodcache: filepath.Join(root, "modcache"),
+				goos:       "linux",
+				goarch:     "amd64",
+			}
+			r := env.resolveToolchain(filepath.Join(root, test.dir))
+			if (r.Error != "") != test.wantErr {
+				t.Errorf("error = %q, want error %v", r.Error, test.wantErr)
+			}
+			executable := test.executable
+			if executable != "" && !filepath.IsAbs(executable) {
+				executable = filepath.Join(root, executable)
+			}
+			if r.Selected != test.selected || r.Executable != executable || r.Download != test.download {
+				t.Errorf("selected %s %s (download %v), want %s %s (download %v)", r.Selected, r.Executable, r.Download, test.selected, executable, test.download)
+			}
+			if len(r.Steps) == 0 || r.Steps[0].Step != "GOTOOLCHAIN" {
+				t.Errorf("steps = %+v, want GOTOOLCHAIN first", r.Steps)
+			}
+			if t.Failed() {
+				for _, s := range r.Steps {
+					t.Logf("%+v", s)
+				}
+			}
+		})
+	}
+}